@@ -0,0 +1,84 @@
+// buildinfo.go: Build and git metadata attributes
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// Attribute names stamped by Config.IncludeBuildInfo
+const (
+	buildCommitKey = "git.commit.sha"
+	buildBranchKey = "git.branch"
+	buildTimeKey   = "build.time"
+)
+
+// buildInfo is the metadata set by SetBuildInfo
+type buildInfo struct {
+	commit    string
+	buildTime string
+	branch    string
+}
+
+var (
+	buildInfoValue  atomic.Pointer[buildInfo]
+	moduleBuildInfo buildInfo
+	moduleBuildOnce sync.Once
+)
+
+// SetBuildInfo sets the build metadata stamped on entries of writers with
+// Config.IncludeBuildInfo, typically from variables filled by -ldflags.
+// Empty values are omitted. Until it is called, the VCS revision and time
+// recorded by the Go toolchain are used when available.
+func SetBuildInfo(commit, buildTime, branch string) {
+	buildInfoValue.Store(&buildInfo{commit: commit, buildTime: buildTime, branch: branch})
+}
+
+// currentBuildInfo returns the SetBuildInfo metadata, falling back to the
+// binary's embedded VCS settings
+func currentBuildInfo() buildInfo {
+	if info := buildInfoValue.Load(); info != nil {
+		return *info
+	}
+	moduleBuildOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			moduleBuildInfo = vcsBuildInfo(info)
+		}
+	})
+	return moduleBuildInfo
+}
+
+// vcsBuildInfo extracts the VCS revision and commit time the Go toolchain
+// embeds in binaries built from a checkout
+func vcsBuildInfo(info *debug.BuildInfo) buildInfo {
+	var build buildInfo
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.commit = setting.Value
+		case "vcs.time":
+			build.buildTime = setting.Value
+		}
+	}
+	return build
+}
+
+// applyBuildInfo stamps the build metadata attributes that are known
+func applyBuildInfo(fields map[string]any) {
+	info := currentBuildInfo()
+	for key, value := range map[string]string{
+		buildCommitKey: info.commit,
+		buildTimeKey:   info.buildTime,
+		buildBranchKey: info.branch,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// buildinfo_test.go: Build metadata attribute tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_IncludeBuildInfo(t *testing.T) {
+	previous := buildInfoValue.Load()
+	t.Cleanup(func() { buildInfoValue.Store(previous) })
+	SetBuildInfo("3f2a9c1", "2025-09-06T10:00:00Z", "")
+
+	writer := &Writer{config: Config{IncludeBuildInfo: true}}
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Info, "built"))
+	if entry.Fields[buildCommitKey] != "3f2a9c1" || entry.Fields[buildTimeKey] != "2025-09-06T10:00:00Z" {
+		t.Errorf("Fields = %v, want commit and build time", entry.Fields)
+	}
+	if _, ok := entry.Fields[buildBranchKey]; ok {
+		t.Error("empty branch should be omitted")
+	}
+
+	writer.config.IncludeBuildInfo = false
+	entry = writer.buildLogEntry(iris.NewRecord(iris.Info, "built"))
+	if _, ok := entry.Fields[buildCommitKey]; ok {
+		t.Error("build info stamped without IncludeBuildInfo")
+	}
+}
+
+func TestVCSBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{Settings: []debug.BuildSetting{
+		{Key: "vcs", Value: "git"},
+		{Key: "vcs.revision", Value: "abc123"},
+		{Key: "vcs.time", Value: "2025-09-01T08:00:00Z"},
+	}}
+	got := vcsBuildInfo(info)
+	if got.commit != "abc123" || got.buildTime != "2025-09-01T08:00:00Z" {
+		t.Errorf("vcsBuildInfo() = %+v", got)
+	}
+	if got := vcsBuildInfo(&debug.BuildInfo{}); got != (buildInfo{}) {
+		t.Errorf("vcsBuildInfo() without VCS settings = %+v, want empty", got)
+	}
+}
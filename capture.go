@@ -0,0 +1,78 @@
+// capture.go: In-memory payload capture for tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import "net/http"
+
+// CapturedBatch is one intake request recorded by a writer in CaptureMode
+type CapturedBatch struct {
+	// Entries are the log entries of the batch
+	Entries []LogEntry
+
+	// Bytes is the size of the request body, after compression
+	Bytes int
+
+	// Headers are the request headers, including DD-API-KEY
+	Headers http.Header
+
+	// Event marks an Events API post (Config.EmitEventsAboveLevel); its
+	// Entries hold the single record the event was built from
+	Event bool
+}
+
+// CapturedBatches returns a copy of every batch captured so far, oldest
+// first, followed by those of each partition in key order. It is safe to
+// call concurrently with writes and flushes.
+func (w *Writer) CapturedBatches() []CapturedBatch {
+	w.captureMutex.Lock()
+	batches := make([]CapturedBatch, len(w.captured))
+	for i, batch := range w.captured {
+		batches[i] = CapturedBatch{
+			Entries: copyEntries(batch.Entries),
+			Bytes:   batch.Bytes,
+			Headers: batch.Headers.Clone(),
+			Event:   batch.Event,
+		}
+	}
+	w.captureMutex.Unlock()
+
+	for _, partition := range w.partitionList() {
+		batches = append(batches, partition.CapturedBatches()...)
+	}
+	return batches
+}
+
+// capture records a request instead of sending it
+func (w *Writer) capture(entries []LogEntry, request intakeRequest) {
+	header := make(http.Header)
+	w.setIntakeHeaders(header, request)
+	batch := CapturedBatch{
+		Entries: copyEntries(entries),
+		Bytes:   len(request.body),
+		Headers: header,
+	}
+
+	w.captureMutex.Lock()
+	w.captured = append(w.captured, batch)
+	w.captureMutex.Unlock()
+}
+
+// captureEvent records an Events API post instead of sending it
+func (w *Writer) captureEvent(entry LogEntry, body []byte) {
+	header := make(http.Header)
+	w.setRequestHeaders(header, contentTypeJSON, "")
+	batch := CapturedBatch{
+		Entries: copyEntries([]LogEntry{entry}),
+		Bytes:   len(body),
+		Headers: header,
+		Event:   true,
+	}
+
+	w.captureMutex.Lock()
+	w.captured = append(w.captured, batch)
+	w.captureMutex.Unlock()
+}
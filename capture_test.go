@@ -0,0 +1,59 @@
+// capture_test.go: In-memory payload capture tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_CaptureMode(t *testing.T) {
+	writer, err := New(Config{
+		Service:           "capture",
+		CaptureMode:       true,
+		EnableCompression: true,
+		BatchSize:         2,
+		FlushInterval:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for _, msg := range []string{"one", "two", "three"} {
+		if err := writer.WriteRecord(iris.NewRecord(iris.Info, msg)); err != nil {
+			t.Fatalf("WriteRecord() error = %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	batches := writer.CapturedBatches()
+	if len(batches) != 2 {
+		t.Fatalf("captured %d batches, want 2", len(batches))
+	}
+	if len(batches[0].Entries) != 2 || batches[0].Entries[0].Message != "one" {
+		t.Errorf("first batch = %+v, want entries one and two", batches[0].Entries)
+	}
+	if batches[1].Entries[0].Message != "three" {
+		t.Errorf("second batch message = %q, want three", batches[1].Entries[0].Message)
+	}
+	if batches[0].Bytes == 0 || batches[0].Headers.Get("Content-Encoding") != "gzip" {
+		t.Errorf("batch bytes = %d, headers = %v", batches[0].Bytes, batches[0].Headers)
+	}
+	if stats := writer.Stats(); stats.EntriesSent != 3 || stats.Requests != 0 {
+		t.Errorf("EntriesSent = %d, Requests = %d, want 3 and 0", stats.EntriesSent, stats.Requests)
+	}
+
+	batches[0].Entries[0].Message = "mutated"
+	if writer.CapturedBatches()[0].Entries[0].Message != "one" {
+		t.Error("CapturedBatches returned shared entries")
+	}
+}
@@ -0,0 +1,71 @@
+// checksum.go: Per-entry integrity checksums
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// checksumKey is the attribute holding the entry checksum
+	checksumKey = "dd.checksum"
+
+	// defaultChecksumBytes truncates the checksum to 128 bits
+	defaultChecksumBytes = 16
+)
+
+// checksumEntries stamps the entries of a batch about to be encoded. It
+// runs at serialization, after correlation IDs, coalesced repeat counts,
+// joined continuations, truncation and MinimalPayload changed the
+// entries, so every checksum matches the entry as shipped.
+func (w *Writer) checksumEntries(entries []LogEntry) error {
+	for i := range entries {
+		if err := w.stampChecksum(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stampChecksum sets "dd.checksum" to a hash of the entry's message and
+// attributes, other than "dd.checksum" itself. The input is a JSON object
+// of both with keys in sorted order, so consumers can recompute it from
+// the received log.
+func (w *Writer) stampChecksum(entry *LogEntry) error {
+	content := make(map[string]any, len(entry.Fields)+1)
+	for key, value := range entry.Fields {
+		if key != checksumKey {
+			content[key] = value
+		}
+	}
+	content["message"] = entry.Message
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry for checksum: %w", err)
+	}
+
+	var sum []byte
+	if w.config.ChecksumHash != nil {
+		h := w.config.ChecksumHash()
+		_, _ = h.Write(data)
+		sum = h.Sum(nil)
+	} else {
+		digest := sha256.Sum256(data)
+		sum = digest[:]
+	}
+	if n := w.config.ChecksumBytes; n > 0 && n < len(sum) {
+		sum = sum[:n]
+	}
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]any, 1)
+	}
+	entry.Fields[checksumKey] = hex.EncodeToString(sum)
+	return nil
+}
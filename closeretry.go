@@ -0,0 +1,73 @@
+// closeretry.go: Bounded final flush and background delivery after Close
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDrainTimeout is returned by Close when the final flush did not finish
+// within Config.DrainTimeout. The flush keeps running in the background.
+var ErrDrainTimeout = errors.New("final flush did not complete within DrainTimeout")
+
+// drain runs the final flush, giving up waiting after Config.DrainTimeout
+func (w *Writer) drain() error {
+	if w.config.DrainTimeout <= 0 {
+		return w.flush()
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- w.flush() }()
+
+	timer := time.NewTimer(w.config.DrainTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return ErrDrainTimeout
+	}
+}
+
+// retryPolicy returns the retry delay and retry count for a send, using
+// Config.CloseRetryDelay and CloseMaxRetries for the final flush
+func (w *Writer) retryPolicy(finalFlush bool) (time.Duration, int) {
+	delay, retries := w.config.RetryDelay, w.config.MaxRetries
+	if !finalFlush {
+		return delay, retries
+	}
+	if w.config.CloseRetryDelay != 0 {
+		delay = max(w.config.CloseRetryDelay, 0)
+	}
+	if w.config.CloseMaxRetries != 0 {
+		retries = max(w.config.CloseMaxRetries, 0)
+	}
+	return delay, retries
+}
+
+// retryAfterClose keeps trying to deliver a batch that failed after Close,
+// for at most Config.BackgroundCloseRetry or until a permanent failure.
+// Only then is the batch reported, once: to OnError, in the stats and to
+// Config.OnDropBatch.
+func (w *Writer) retryAfterClose(entries []LogEntry, correlationID string, err error) {
+	deadline := time.Now().Add(w.config.BackgroundCloseRetry)
+	for time.Now().Add(w.config.RetryDelay).Before(deadline) {
+		time.Sleep(w.config.RetryDelay)
+		if err = w.sendToDatadog(entries, correlationID, false); err == nil {
+			w.stats.recoveredAfterClose.Add(uint64(len(entries)))
+			return
+		}
+		if isPermanent(err) {
+			break
+		}
+	}
+	w.failBatch(entries, err)
+	if w.config.OnDropBatch != nil {
+		w.config.OnDropBatch(entries, err)
+	}
+}
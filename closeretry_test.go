@@ -0,0 +1,240 @@
+// closeretry_test.go: Bounded final flush and background retry tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_DrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval: time.Hour,
+		DrainTimeout:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "slow"))
+
+	start := time.Now()
+	if err := writer.Close(); !errors.Is(err, ErrDrainTimeout) {
+		t.Errorf("Close() error = %v, want ErrDrainTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close() took %v, want about DrainTimeout", elapsed)
+	}
+}
+
+func TestWriter_BackgroundCloseRetry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:               "test-key",
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:        time.Hour,
+		MaxRetries:           1,
+		RetryDelay:           10 * time.Millisecond,
+		BackgroundCloseRetry: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "last words"))
+
+	if err := writer.Close(); err == nil {
+		t.Fatal("Close() error = nil, want the final flush failure")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for writer.Stats().EntriesRecoveredAfterClose == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := writer.Stats().EntriesRecoveredAfterClose; got != 1 {
+		t.Errorf("EntriesRecoveredAfterClose = %d, want 1", got)
+	}
+}
+
+func TestWriter_BackgroundCloseRetryCountsOnce(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		status   int
+		requests int32 // Requests expected, including the final flush
+	}{
+		{"retryable", http.StatusInternalServerError, 0},
+		{"permanent", http.StatusForbidden, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			var errorsReported, batchesDropped atomic.Int32
+			done := make(chan struct{})
+			writer, err := New(Config{
+				APIKey:               "test-key",
+				Site:                 strings.TrimPrefix(server.URL, "http://"),
+				FlushInterval:        time.Hour,
+				MaxRetries:           1,
+				RetryDelay:           10 * time.Millisecond,
+				BackgroundCloseRetry: 100 * time.Millisecond,
+				OnError:              func(error) { errorsReported.Add(1) },
+				OnDropBatch: func([]LogEntry, error) {
+					batchesDropped.Add(1)
+					close(done)
+				},
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			_ = writer.WriteRecord(iris.NewRecord(iris.Error, "last words"))
+			_ = writer.Close()
+
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatal("Timed out waiting for OnDropBatch")
+			}
+			if tt.requests > 0 && requests.Load() != tt.requests {
+				t.Errorf("requests = %d, want %d with no background retry", requests.Load(), tt.requests)
+			}
+			if tt.requests == 0 && requests.Load() < 3 {
+				t.Errorf("requests = %d, want background retries", requests.Load())
+			}
+			stats := writer.Stats()
+			if stats.EntriesDropped != 1 || stats.ConsecutiveFailures != 1 {
+				t.Errorf("EntriesDropped = %d, ConsecutiveFailures = %d, want the batch counted once", stats.EntriesDropped, stats.ConsecutiveFailures)
+			}
+			if errorsReported.Load() != 1 || batchesDropped.Load() != 1 {
+				t.Errorf("OnError calls = %d, OnDropBatch calls = %d, want 1 each", errorsReported.Load(), batchesDropped.Load())
+			}
+		})
+	}
+}
+
+func TestWriter_BackgroundCloseRetryDoubleBuffer(t *testing.T) {
+	var retried atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []LogEntry
+		if err := json.NewDecoder(r.Body).Decode(&entries); err == nil && len(entries) > 0 && entries[0].Message == "one" {
+			retried.Add(1)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	dropped := make(chan []string, 1)
+	writer, err := New(Config{
+		APIKey:               "test-key",
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:        time.Hour,
+		RetryDelay:           10 * time.Millisecond,
+		BackgroundCloseRetry: 100 * time.Millisecond,
+		DoubleBuffer:         true,
+		OnError:              func(error) {},
+		OnDropBatch: func(entries []LogEntry, err error) {
+			messages := make([]string, len(entries))
+			for i, entry := range entries {
+				messages[i] = entry.Message
+			}
+			dropped <- messages
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, msg := range []string{"one", "two", "three"} {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Error, msg))
+	}
+	_ = writer.Close()
+
+	select {
+	case messages := <-dropped:
+		if want := []string{"one", "two", "three"}; !slices.Equal(messages, want) {
+			t.Errorf("OnDropBatch entries = %q, want %q", messages, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnDropBatch was not called after BackgroundCloseRetry")
+	}
+	// The final flush plus at least one background retry carried the entries
+	if got := retried.Load(); got < 2 {
+		t.Errorf("requests with the original entries = %d, want at least 2", got)
+	}
+}
+
+func TestWriter_CloseRetryPolicy(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:          "test-api-key",
+		Site:            strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:   time.Hour,
+		MaxRetries:      5,
+		RetryDelay:      time.Second,
+		CloseRetryDelay: -1,
+		CloseMaxRetries: 2,
+		OnError:         func(error) {},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "shutdown"))
+
+	start := time.Now()
+	_ = writer.Close()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Close took %v, want retries without the 1s RetryDelay", elapsed)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 1 attempt + CloseMaxRetries 2", got)
+	}
+}
+
+func TestWriter_RetryPolicyDefaults(t *testing.T) {
+	writer := &Writer{config: Config{RetryDelay: time.Second, MaxRetries: 3, CloseRetryDelay: 10 * time.Millisecond}}
+	if delay, retries := writer.retryPolicy(false); delay != time.Second || retries != 3 {
+		t.Errorf("regular policy = %v, %d", delay, retries)
+	}
+	if delay, retries := writer.retryPolicy(true); delay != 10*time.Millisecond || retries != 3 {
+		t.Errorf("final flush policy = %v, %d, want CloseRetryDelay and MaxRetries", delay, retries)
+	}
+}
@@ -0,0 +1,56 @@
+// compress.go: Gzip compression of request bodies
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// compressPayload compresses a request body with gzipPayload, or with the
+// compressor a test installed
+func (w *Writer) compressPayload(payload []byte) ([]byte, error) {
+	if w.compressor != nil {
+		return w.compressor(payload)
+	}
+	return w.gzipPayload(payload)
+}
+
+// gzipPayload compresses payload into an independent, complete gzip
+// stream. With Config.PersistentCompressor a single encoder is reset and
+// reused for every batch instead of allocating one per flush.
+func (w *Writer) gzipPayload(payload []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, len(payload)/4))
+
+	if !w.config.PersistentCompressor {
+		return finishGzip(gzip.NewWriter(buf), buf, payload)
+	}
+
+	w.gzipMutex.Lock()
+	defer w.gzipMutex.Unlock()
+	if w.gzipWriter == nil {
+		w.gzipWriter = gzip.NewWriter(buf)
+	} else {
+		w.gzipWriter.Reset(buf)
+	}
+	body, err := finishGzip(w.gzipWriter, buf, payload)
+	w.gzipWriter.Reset(io.Discard) // Drop the reference to buf
+	return body, err
+}
+
+// finishGzip writes payload through gz and closes the stream
+func finishGzip(gz *gzip.Writer, buf *bytes.Buffer, payload []byte) ([]byte, error) {
+	if _, err := gz.Write(payload); err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+	return buf.Bytes(), nil
+}
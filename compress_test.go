@@ -0,0 +1,109 @@
+// compress_test.go: Request body compression tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func gunzip(t *testing.T, body []byte) string {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestWriter_PersistentCompressor(t *testing.T) {
+	writer := &Writer{config: Config{PersistentCompressor: true}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			payload := fmt.Sprintf(`[{"message":"batch %d"}]`, i)
+			body, err := writer.gzipPayload([]byte(payload))
+			if err != nil {
+				t.Errorf("gzipPayload() error = %v", err)
+				return
+			}
+			if got := gunzip(t, body); got != payload {
+				t.Errorf("decompressed = %q, want %q", got, payload)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func benchmarkGzipPayload(b *testing.B, persistent bool) {
+	writer := &Writer{config: Config{PersistentCompressor: persistent}}
+	payload := bytes.Repeat([]byte(`{"status":"info","message":"request served","service":"api"},`), 500)
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := writer.gzipPayload(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGzipPayload_PerFlush(b *testing.B)   { benchmarkGzipPayload(b, false) }
+func BenchmarkGzipPayload_Persistent(b *testing.B) { benchmarkGzipPayload(b, true) }
+
+func TestWriter_CompressionFailureFallsBackToUncompressed(t *testing.T) {
+	var encoding, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		encoding, body = r.Header.Get("Content-Encoding"), string(data)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var errs []error
+	writer, err := New(Config{
+		APIKey:            "test-api-key",
+		Site:              strings.TrimPrefix(server.URL, "http://"),
+		EnableCompression: true,
+		OnError:           func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	writer.compressor = func([]byte) ([]byte, error) { return nil, errors.New("gzip: broken") }
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "still delivered"))
+	_ = writer.Close()
+
+	if encoding != "" || !strings.Contains(body, "still delivered") {
+		t.Errorf("request = %q encoded %q, want the uncompressed batch", body, encoding)
+	}
+	stats := writer.Stats()
+	if stats.EntriesSent != 1 || stats.EntriesDropped != 0 || stats.CompressionFallbacks != 1 {
+		t.Errorf("Stats() = %+v, want 1 sent, 0 dropped, 1 fallback", stats)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "gzip: broken") {
+		t.Errorf("OnError calls = %v, want the compression failure", errs)
+	}
+}
@@ -0,0 +1,41 @@
+// concurrency.go: Request concurrency limiting for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+	"time"
+)
+
+// acquireSlot waits for a free request slot, giving up after
+// Config.AcquireTimeout. It is a no-op when concurrency is unlimited.
+func (w *Writer) acquireSlot() error {
+	if w.slots != nil {
+		select {
+		case w.slots <- struct{}{}:
+		default:
+			timer := time.NewTimer(w.config.AcquireTimeout)
+			defer timer.Stop()
+
+			select {
+			case w.slots <- struct{}{}:
+			case <-timer.C:
+				return fmt.Errorf("timed out after %v waiting for a request slot", w.config.AcquireTimeout)
+			}
+		}
+	}
+	w.active.Add(1)
+	return nil
+}
+
+// releaseSlot frees the slot taken by acquireSlot
+func (w *Writer) releaseSlot() {
+	w.active.Add(-1)
+	if w.slots != nil {
+		<-w.slots
+	}
+}
@@ -0,0 +1,299 @@
+// concurrency_test.go: Request concurrency limiting tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_MaxConcurrentRequests(t *testing.T) {
+	var current, peak atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		current.Add(-1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:                "test-api-key",
+		Site:                  strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:             1,
+		FlushInterval:         time.Hour,
+		MaxConcurrentRequests: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	var observed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = writer.WriteRecord(iris.NewRecord(iris.Info, "concurrent"))
+			if active := writer.Stats().ActiveRequests; active > observed.Load() {
+				observed.Store(active)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("Peak concurrent requests = %d, want at most 2", got)
+	}
+	if got := writer.Stats().EntriesSent; got != 8 {
+		t.Errorf("EntriesSent = %d, want 8", got)
+	}
+	if observed.Load() > 2 {
+		t.Errorf("ActiveRequests reported %d, want at most 2", observed.Load())
+	}
+}
+
+func TestWriter_AcquireTimeout(t *testing.T) {
+	writer := &Writer{
+		config: Config{AcquireTimeout: 10 * time.Millisecond},
+		slots:  make(chan struct{}, 1),
+	}
+
+	if err := writer.acquireSlot(); err != nil {
+		t.Fatalf("First acquire failed: %v", err)
+	}
+	if err := writer.acquireSlot(); err == nil {
+		t.Fatal("Expected second acquire to time out")
+	}
+	writer.releaseSlot()
+	if err := writer.acquireSlot(); err != nil {
+		t.Errorf("Acquire after release failed: %v", err)
+	}
+}
+
+func TestNew_AcquireTimeoutDefault(t *testing.T) {
+	writer, err := New(Config{APIKey: "test-api-key", Timeout: 3 * time.Second, MaxConcurrentRequests: 1})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if writer.config.AcquireTimeout != 3*time.Second {
+		t.Errorf("AcquireTimeout = %v, want Timeout", writer.config.AcquireTimeout)
+	}
+}
+
+func TestWriter_ConcurrentFlushTriggersCoalesce(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:   true,
+		BatchSize:     8,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	const writers, perWriter = 8, 500
+	var wg sync.WaitGroup
+	for g := 0; g < writers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				_ = writer.WriteRecord(iris.NewRecord(iris.Info, "contended"))
+				if i%50 == 0 {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	total := 0
+	for _, batch := range writer.CapturedBatches() {
+		total += len(batch.Entries)
+	}
+	if total != writers*perWriter {
+		t.Errorf("captured %d entries, want %d exactly once", total, writers*perWriter)
+	}
+	if sent := writer.Stats().EntriesSent; sent != writers*perWriter {
+		t.Errorf("EntriesSent = %d, want %d", sent, writers*perWriter)
+	}
+}
+
+func TestWriter_TimedFlushSkippedAfterRecentFlush(t *testing.T) {
+	writer := &Writer{config: Config{BatchSize: 10}}
+	writer.buffer = []LogEntry{{Message: "straggler"}}
+
+	writer.lastFlush = time.Now()
+	if writer.timedFlushDue() {
+		t.Error("timed flush right after another flush must be skipped")
+	}
+	writer.lastFlush = time.Now().Add(-flushCoalesceWindow)
+	if !writer.timedFlushDue() {
+		t.Error("timed flush outside the coalesce window must send")
+	}
+}
+
+// writeMixed runs bulk and single writers concurrently; every message is
+// "<goroutine>/<sequence>"
+func writeMixed(t *testing.T, writer *Writer, goroutines, perGoroutine int) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			if g%2 == 0 {
+				for seq := 0; seq < perGoroutine; seq++ {
+					_ = writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("%d/%d", g, seq)))
+				}
+				return
+			}
+			for start := 0; start < perGoroutine; start += 50 {
+				records := make([]*iris.Record, 0, 50)
+				for seq := start; seq < start+50 && seq < perGoroutine; seq++ {
+					records = append(records, iris.NewRecord(iris.Info, fmt.Sprintf("%d/%d", g, seq)))
+				}
+				if err := writer.WriteRecords(records); err != nil {
+					t.Errorf("WriteRecords() error = %v", err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// checkGoroutineOrder reports entries that appear out of call order for
+// their goroutine, tracking the next expected sequence in next
+func checkGoroutineOrder(t *testing.T, entries []LogEntry, next map[int]int) {
+	t.Helper()
+	for _, entry := range entries {
+		var g, seq int
+		if _, err := fmt.Sscanf(entry.Message, "%d/%d", &g, &seq); err != nil {
+			t.Fatalf("unexpected message %q", entry.Message)
+		}
+		if seq < next[g] {
+			t.Errorf("goroutine %d: entry %d after %d", g, seq, next[g]-1)
+		}
+		next[g] = seq + 1
+	}
+}
+
+func TestWriter_MixedBulkAndSingleWritersKeepOrder(t *testing.T) {
+	const goroutines, perGoroutine = 8, 500
+
+	writer, err := New(Config{CaptureMode: true, BatchSize: goroutines * perGoroutine * 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	writeMixed(t, writer, goroutines, perGoroutine)
+
+	entries := writer.Snapshot()
+	if len(entries) != goroutines*perGoroutine {
+		t.Fatalf("buffered %d entries, want %d", len(entries), goroutines*perGoroutine)
+	}
+	next := make(map[int]int)
+	checkGoroutineOrder(t, entries, next)
+	for g := 0; g < goroutines; g++ {
+		if next[g] != perGoroutine {
+			t.Errorf("goroutine %d: last entry %d, want %d", g, next[g]-1, perGoroutine-1)
+		}
+	}
+}
+
+func TestWriter_MixedBulkAndSingleWritersLoseNothing(t *testing.T) {
+	const goroutines, perGoroutine = 8, 500
+
+	writer, err := New(Config{CaptureMode: true, BatchSize: 64, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	writeMixed(t, writer, goroutines, perGoroutine)
+	_ = writer.Close()
+
+	total := 0
+	for _, batch := range writer.CapturedBatches() {
+		total += len(batch.Entries)
+		// Batches may be delivered out of order, entries within one may not
+		checkGoroutineOrder(t, batch.Entries, make(map[int]int))
+	}
+	if total != goroutines*perGoroutine {
+		t.Errorf("delivered %d entries, want %d", total, goroutines*perGoroutine)
+	}
+}
+
+func TestWriter_SortBatchByTime(t *testing.T) {
+	const goroutines, perGoroutine = 8, 100
+
+	writer, err := New(Config{CaptureMode: true, BatchSize: goroutines * perGoroutine, FlushInterval: time.Hour, SortBatchByTime: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	base := time.Now().Add(-time.Minute)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			// Goroutines own interleaved instants, so lock order scrambles them
+			for i := 0; i < perGoroutine; i++ {
+				at := base.Add(time.Duration(i*goroutines+g) * time.Millisecond)
+				_ = writer.WriteRecordAt(at, iris.NewRecord(iris.Info, "event"))
+			}
+		}(g)
+	}
+	wg.Wait()
+	_ = writer.Close()
+
+	batches := writer.CapturedBatches()
+	if len(batches) != 1 || len(batches[0].Entries) != goroutines*perGoroutine {
+		t.Fatalf("captured %d batches, want one full batch", len(batches))
+	}
+	entries := batches[0].Entries
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Timestamp < entries[i-1].Timestamp {
+			t.Fatalf("entry %d at %d sent after %d", i, entries[i].Timestamp, entries[i-1].Timestamp)
+		}
+	}
+}
+
+func TestSortByTimeKeepsBufferOrderForTies(t *testing.T) {
+	entries := []LogEntry{
+		{Timestamp: 2, Message: "c"},
+		{Timestamp: 1, Message: "a"},
+		{Timestamp: 2, Message: "d"},
+		{Timestamp: 1, Message: "b"},
+	}
+	sortByTime(entries)
+	var got string
+	for _, entry := range entries {
+		got += entry.Message
+	}
+	if got != "abcd" {
+		t.Errorf("order = %q, want %q", got, "abcd")
+	}
+}
@@ -0,0 +1,94 @@
+// cooldown.go: Retry-After aware send cooldown for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrCooldown is reported for batches Close could not send because the
+// intake paused sends (Retry-After or rate-limit headers) beyond
+// Config.DrainTimeout. The entries are counted as dropped and passed to
+// Config.OnDropBatch.
+var ErrCooldown = errors.New("sends paused by intake cooldown past DrainTimeout")
+
+// parseRetryAfter interprets a Retry-After header given either as a number
+// of seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// startCooldown stops all sends, of every partition, for the given
+// duration. Overlapping cooldowns extend to the latest deadline.
+func (w *Writer) startCooldown(delay time.Duration) {
+	root := w.root()
+	until := time.Now().Add(delay).UnixNano()
+	for {
+		current := root.cooldownUntil.Load()
+		if current >= until || root.cooldownUntil.CompareAndSwap(current, until) {
+			return
+		}
+	}
+}
+
+// inCooldown reports whether sends are paused by a Retry-After response
+func (w *Writer) inCooldown() bool {
+	return time.Now().UnixNano() < w.root().cooldownUntil.Load()
+}
+
+// awaitCooldown waits for the cooldown to end. Without DrainTimeout Close
+// waits for the final flush however long it takes, so it always waits;
+// otherwise it reports false when the cooldown outlasts the Close
+// deadline, so the batch is given up instead.
+func (w *Writer) awaitCooldown() bool {
+	until := w.root().cooldownUntil.Load()
+	if deadline := w.drainDeadline.Load(); deadline != 0 && until > deadline {
+		return false
+	}
+	time.Sleep(time.Until(time.Unix(0, until)))
+	return true
+}
+
+// requeue puts entries back at the front of the buffer so they are sent,
+// in order, once the cooldown has passed. Once the writer is closed no
+// later flush would send them, so it reports false and leaves the batch
+// to the caller.
+func (w *Writer) requeue(entries []LogEntry) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	// Checked under mutex: Close marks the writer closed before its final
+	// flush takes the buffer, so requeued entries are always in that flush
+	if w.closed.Load() {
+		return false
+	}
+	merged := make([]LogEntry, 0, len(entries)+len(w.buffer))
+	merged = append(merged, entries...)
+	w.buffer = append(merged, w.buffer...)
+	for _, entry := range entries {
+		w.bufferBytes += entry.size
+		w.noteBuffered(entry.Timestamp)
+	}
+	return true
+}
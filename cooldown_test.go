@@ -0,0 +1,213 @@
+// cooldown_test.go: Retry-After aware send cooldown tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 9, 6, 12, 0, 0, 0, time.UTC)
+
+	if d, ok := parseRetryAfter("3", now); !ok || d != 3*time.Second {
+		t.Errorf("parseRetryAfter(3) = %v, %v", d, ok)
+	}
+	if d, ok := parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now); !ok || d != 10*time.Second {
+		t.Errorf("parseRetryAfter(date) = %v, %v", d, ok)
+	}
+	if _, ok := parseRetryAfter("soon", now); ok {
+		t.Error("Expected an invalid value to be rejected")
+	}
+	if _, ok := parseRetryAfter("", now); ok {
+		t.Error("Expected an empty value to be rejected")
+	}
+}
+
+func TestWriter_RetryAfterCooldown(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "rate limited"))
+	if !writer.Stats().InCooldown {
+		t.Fatal("Expected the writer to be in cooldown after a 429 with Retry-After")
+	}
+
+	// Sends during the cooldown keep entries buffered instead of firing
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "during cooldown"))
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Requests during cooldown = %d, want 1", got)
+	}
+	writer.mutex.Lock()
+	buffered := len(writer.buffer)
+	writer.mutex.Unlock()
+	if buffered != 2 {
+		t.Errorf("Buffered entries = %d, want 2", buffered)
+	}
+
+	// Simulate the cooldown passing
+	writer.cooldownUntil.Store(0)
+	if err := writer.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+	if got := writer.Stats().EntriesSent; got != 2 {
+		t.Errorf("EntriesSent = %d, want 2", got)
+	}
+}
+
+func TestWriter_CloseDuringCooldownDropsBatch(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var dropped []string
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		DrainTimeout:  time.Second,
+		OnDropBatch: func(entries []LogEntry, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, entry := range entries {
+				dropped = append(dropped, entry.Message)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "rate limited"))
+	if !writer.Stats().InCooldown {
+		t.Fatal("Expected the writer to be in cooldown after a 429 with Retry-After")
+	}
+
+	start := time.Now()
+	if err := writer.Close(); !errors.Is(err, ErrCooldown) {
+		t.Errorf("Close() error = %v, want ErrCooldown", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Close() took %v, want no wait for a cooldown past DrainTimeout", elapsed)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Requests = %d, want 1", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dropped) != 1 || dropped[0] != "rate limited" {
+		t.Errorf("OnDropBatch entries = %q, want [rate limited]", dropped)
+	}
+	if got := writer.Stats().EntriesDropped; got != 1 {
+		t.Errorf("EntriesDropped = %d, want 1", got)
+	}
+}
+
+func TestWriter_CloseWaitsOutShortCooldown(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		DrainTimeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "rate limited"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Requests = %d, want 2", got)
+	}
+	if got := writer.Stats().EntriesSent; got != 1 {
+		t.Errorf("EntriesSent = %d, want 1", got)
+	}
+}
+
+func TestWriter_CloseWaitsOutCooldownWithoutDrainTimeout(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "rate limited"))
+	if !writer.Stats().InCooldown {
+		t.Fatal("Expected the writer to be in cooldown after a 429 with Retry-After")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Requests = %d, want 2", got)
+	}
+	if stats := writer.Stats(); stats.EntriesSent != 1 || stats.EntriesDropped != 0 {
+		t.Errorf("EntriesSent = %d, EntriesDropped = %d, want the batch sent after the cooldown", stats.EntriesSent, stats.EntriesDropped)
+	}
+}
@@ -0,0 +1,46 @@
+// correlation.go: Per-flush correlation IDs for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// correlationID returns a new ID for a flush, or "" when correlation is
+// not configured
+func (w *Writer) correlationID() string {
+	if w.config.CorrelationHeader == "" && w.config.CorrelationAttribute == "" {
+		return ""
+	}
+	if w.config.CorrelationIDGenerator != nil {
+		return w.config.CorrelationIDGenerator()
+	}
+	return newUUID()
+}
+
+// stampCorrelation sets Config.CorrelationAttribute on every entry
+func (w *Writer) stampCorrelation(entries []LogEntry, id string) {
+	if id == "" || w.config.CorrelationAttribute == "" {
+		return
+	}
+	for i := range entries {
+		if entries[i].Fields == nil {
+			entries[i].Fields = make(map[string]any)
+		}
+		entries[i].Fields[w.config.CorrelationAttribute] = id
+	}
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,83 @@
+// correlation_test.go: Per-flush correlation ID tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_CorrelationID(t *testing.T) {
+	var mu sync.Mutex
+	var headers []string
+	var attributes []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&logs)
+
+		mu.Lock()
+		headers = append(headers, r.Header.Get("X-Correlation-Id"))
+		for _, log := range logs {
+			attributes = append(attributes, log["batch_id"])
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	flushes := 0
+	writer, err := New(Config{
+		APIKey:               "test-api-key",
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:        time.Hour,
+		CorrelationHeader:    "X-Correlation-Id",
+		CorrelationAttribute: "batch_id",
+		CorrelationIDGenerator: func() string {
+			flushes++
+			return fmt.Sprintf("flush-%d", flushes)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "first"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "second"))
+	_ = writer.Flush()
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "third"))
+	_ = writer.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"flush-1", "flush-2"}; fmt.Sprint(headers) != fmt.Sprint(want) {
+		t.Errorf("headers = %v, want %v", headers, want)
+	}
+	if want := []any{"flush-1", "flush-1", "flush-2"}; fmt.Sprint(attributes) != fmt.Sprint(want) {
+		t.Errorf("attributes = %v, want %v", attributes, want)
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := newUUID(), newUUID()
+	if !pattern.MatchString(a) {
+		t.Errorf("newUUID() = %q, not a version 4 UUID", a)
+	}
+	if a == b {
+		t.Error("newUUID() returned the same value twice")
+	}
+}
@@ -8,34 +8,148 @@ package datadogwriter
 
 import (
 	"bytes"
+	"cmp"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"weak"
 
 	"github.com/agilira/go-timecache"
 	"github.com/agilira/iris"
 )
 
+// DatadogWriter is the behaviour of Writer that downstream code depends
+// on. Consumers can accept a DatadogWriter and substitute a fake in tests.
+type DatadogWriter interface {
+	WriteRecord(record *iris.Record) error
+	Flush() error
+	Close() error
+	Stats() Stats
+}
+
+var (
+	_ DatadogWriter   = (*Writer)(nil)
+	_ iris.SyncWriter = (*Writer)(nil)
+)
+
 // Writer implements iris.SyncWriter for Datadog Logs API
 type Writer struct {
-	config     Config
-	client     *http.Client
-	buffer     []LogEntry
+	config Config
+	client *http.Client
+	buffer []LogEntry
+	spare  []LogEntry // Idle buffer swapped in by flush with Config.DoubleBuffer
+
+	bufferBytes    int   // Estimated size of buffer, protected by mutex
+	oldestBuffered int64 // Earliest buffered timestamp (Unix ms, 0 = empty), protected by mutex
+	overLimit      bool  // MaxBufferBytes was hit since the buffer was last emptied, protected by mutex
+
+	inFlightMutex  sync.Mutex       // Protects the fields below
+	inFlightOldest map[uint64]int64 // Earliest timestamp of each batch being delivered
+	inFlightSeq    uint64           // Last batch token handed out
+
 	mutex      sync.Mutex
-	timer      *time.Timer
-	timerMutex sync.Mutex // Protects timer access
-	closed     bool       // Tracks if writer is closed
+	guard      *abandonGuard     // Owns the flush timer, stopped if the writer is collected unclosed
+	cleanup    runtime.Cleanup   // Reports a writer collected without Close
+	timerMutex sync.Mutex        // Protects flush timer re-arming
+	closed     atomic.Bool       // Set by Close; written under timerMutex
+	tags       atomic.Value      // Holds the current *tagSet, read lock-free on the hot path
+	derived    map[string]string // Tags derived from the configuration at New (see derivedTags)
+	stats      writerStats
+	disabled   atomic.Bool // Set once consecutive failures exceed the threshold
+	probeTimer *time.Timer // Re-enables a disabled writer, protected by timerMutex
+
+	outputMutex  sync.Mutex    // Serializes lines in OutputStdout and syslog modes
+	syslogConn   net.Conn      // Syslog connection, protected by outputMutex
+	syslogRedial bool          // Background reconnect running, protected by outputMutex
+	budget       *retryBudget  // Shared retry budget, nil when unlimited
+	inFlight     atomic.Int64  // Number of sendToDatadog calls in progress
+	ageTimer     *time.Timer   // Bounds the age of the oldest buffered entry, protected by mutex
+	deferTimer   *time.Timer   // Deferred size-triggered flush under MinFlushInterval, protected by mutex
+	lastFlush    time.Time     // When the buffer was last taken for delivery, protected by mutex
+	slots        chan struct{} // Bounds concurrent intake requests, nil when unlimited
+	active       atomic.Int64  // Number of intake requests currently in progress
+
+	startedAt int64         // Unix nanos when New created the writer, for Config.IncludeUptime
+	done      chan struct{} // Closed by Close to stop background goroutines
+	closeOnce sync.Once
+
+	omit     map[string]bool // Standard attributes suppressed by Config.OmitAttributes
+	routes   []LevelRoute    // Config.LevelRouting by descending MinLevel
+	ring     *debugRing      // Recent entries for DebugHandler, nil unless Config.DebugRingSize
+	logIDs   *logIDSource    // Default Config.EmitLogID generator
+	logIDSeq atomic.Uint64   // Sequence of the default log IDs
+	exclude  *fieldMatcher   // Attributes dropped by Config.ExcludeFields, nil when none
+
+	allowedServices map[string]bool // Config.AllowedServices, nil when unrestricted
+	disallowedSeen  sync.Map        // Disallowed services already reported to OnError, kept on the root writer
+
+	destinations []*destination            // Config.AdditionalDestinations
+	rateLimit    atomic.Pointer[RateLimit] // Latest intake rate-limit headers
+
+	gzipMutex  sync.Mutex                   // Protects gzipWriter
+	gzipWriter *gzip.Writer                 // Config.PersistentCompressor encoder, created on first use
+	compressor func([]byte) ([]byte, error) // Replaces gzipPayload in tests
+
+	events    sync.WaitGroup // Event workers, awaited by Close
+	eventQ    chan LogEntry  // Events waiting for a worker, nil without EmitEventsAboveLevel
+	wal       *wal           // Write-ahead log, nil without Config.WALDir
+	replaying sync.WaitGroup // Replay of a previous process's WAL, awaited by Close
+
+	partitionsMutex sync.Mutex         // Protects partitions
+	partitions      map[string]*Writer // Config.PartitionField buffers, created on first use
+	parent          *Writer            // Writer a partition belongs to, nil otherwise (see root)
+
+	captureMutex  sync.Mutex      // Protects captured
+	captured      []CapturedBatch // Requests recorded in Config.CaptureMode
+	dnsFailures   atomic.Int64    // Consecutive DNS resolution failures
+	dnsOpenUntil  atomic.Int64    // Unix nanos before which sends fail fast on DNS
+	cooldownUntil atomic.Int64    // Unix nanos before which no request is sent (Retry-After)
+	drainDeadline atomic.Int64    // Unix nanos Close waits for the final flush until, 0 without DrainTimeout
+	sampleSeq     atomic.Int64    // Records considered for sampling
+
+	trace         *httptrace.ClientTrace // Counts new intake connections
+	responses     responseRing           // Most recent intake responses, kept on the root writer
+	lastRequestID atomic.Value           // Most recent Datadog request ID (string), kept on the root writer
+}
+
+// tagSet pairs a tag map with its precomputed ddtags string.
+type tagSet struct {
+	tags   map[string]string
+	ddtags string
 }
 
 // Config holds the configuration for the Datadog writer
 type Config struct {
-	// APIKey is the Datadog API key for authentication
+	// APIKey is the Datadog API key for authentication (required for OutputIntake)
 	APIKey string
 
+	// Output selects HTTP intake delivery (default) or agent-collect stdout mode
+	Output OutputMode
+
+	// SyslogAddress is the host:port of the syslog listener, required for
+	// OutputSyslogTCP and OutputSyslogUDP. A lost connection is redialed
+	// in the background; records written meanwhile are dropped.
+	SyslogAddress string
+
+	// OutputWriter receives JSON lines in OutputStdout mode (default: os.Stdout)
+	OutputWriter io.Writer
+
 	// Site is the Datadog site (e.g., "datadoghq.com", "datadoghq.eu")
 	Site string
 
@@ -48,269 +162,2157 @@ type Config struct {
 	// Version to tag logs with
 	Version string
 
-	// Source to tag logs with (e.g., "go", "application")
+	// Source to tag logs with (e.g., "go", "json", "application"). An
+	// empty Source defaults to "go", which selects Datadog's Go log
+	// pipeline; set NoSource to send no ddsource instead.
 	Source string
 
+	// NoSource disables the "go" default and clears Source, so entries
+	// carry no ddsource unless SourceField or SourceFromLoggerName set one
+	NoSource bool
+
+	// SourceField names a record field whose string value overrides Source
+	// for that entry (e.g. "dd.source")
+	SourceField string
+
+	// SourceFromLoggerName uses the record's logger name, normalized, as
+	// ddsource when SourceField does not supply one
+	SourceFromLoggerName bool
+
+	// LevelRouting stamps a ddsource and tags chosen by the entry's level,
+	// so Datadog index filters can route severities to different indexes.
+	// Routing markers win over Source, SourceField and Tags.
+	LevelRouting []LevelRoute
+
+	// ServiceField names a record field whose string value overrides
+	// Service, for writers forwarding logs of several services
+	ServiceField string
+
+	// AllowedServices restricts the services a record may set through
+	// ServiceField; Service itself is always allowed (empty = unrestricted)
+	AllowedServices []string
+
+	// ServicePolicy selects whether records with a service outside
+	// AllowedServices are remapped to Service (default) or dropped
+	ServicePolicy ServicePolicy
+
+	// ServiceMapping renames services before they are sent, e.g. during a
+	// migration; it applies to Service and ServiceField values alike, after
+	// the AllowedServices check. Unmapped services pass through unchanged.
+	ServiceMapping map[string]string
+
+	// ServiceAttributeNames lists the attributes the service is emitted
+	// under, e.g. {"service", "service.name"} for pipelines that read the
+	// OpenTelemetry convention (default: {"service"})
+	ServiceAttributeNames []string
+
 	// Hostname to tag logs with
 	Hostname string
 
+	// HostnameFields is an ordered list of record field keys checked for a
+	// host value before falling back to Hostname (e.g. DefaultHostnameFields)
+	HostnameFields []string
+
+	// HostnameTagPattern is a regular expression whose named capture groups,
+	// matched against Hostname (or the OS hostname), become tags
+	HostnameTagPattern string
+
+	// MessageFromField names a record field used as the message when the
+	// record has no message of its own
+	MessageFromField string
+
+	// ClientIPField names a record field holding the client IP of a
+	// request; valid IPs are sent as the reserved "network.client.ip"
+	// attribute so Datadog's GeoIP processor enriches them
+	ClientIPField string
+
+	// SampleBelowLevel is the level under which records are sampled at
+	// SampleRate; records at or above it are always kept. Sampling only
+	// sees records the Iris logger's own level filter let through, and
+	// runs after AdditionalDestinations are fanned out: each destination
+	// applies its MinLevel first, then samples on its own. Sampled-out
+	// records count in Stats.EntriesSampled, not EntriesDropped.
+	SampleBelowLevel iris.Level
+
+	// SampleRate is the fraction (0 < rate < 1) of records below
+	// SampleBelowLevel that are kept (0 = sampling disabled)
+	SampleRate float64
+
+	// DefaultLevelStatus is the status used for iris levels the writer
+	// does not know (default: "info")
+	DefaultLevelStatus string
+
+	// OnUnknownLevel is an optional callback invoked when a record carries
+	// an iris level the writer does not know how to map
+	OnUnknownLevel func(iris.Level)
+
+	// JoinContinuations appends the message of records flagged with
+	// "dd.continuation": true to the previous buffered entry, separated by
+	// a newline, so multi-line events such as stack traces stay whole
+	JoinContinuations bool
+
+	// CoalesceDuplicates folds consecutive entries with the same message and
+	// level into the buffered entry, counting repeats in "dd.repeat_count"
+	CoalesceDuplicates bool
+
+	// CoalesceWindow limits coalescing to duplicates within this duration of
+	// the first occurrence (0 = until the buffer is flushed)
+	CoalesceWindow time.Duration
+
+	// OmitAttributes lists standard attributes that are never emitted, even
+	// when populated: "service", "ddsource", "ddtags", "hostname", "env",
+	// "version"
+	OmitAttributes []string
+
+	// GatewayBackoff replaces RetryDelay as the backoff step after a 504
+	// response, where quick retries add to intake congestion (default:
+	// RetryDelay)
+	GatewayBackoff time.Duration
+
+	// GatewayBackoffOn503 also applies GatewayBackoff to 503 responses
+	GatewayBackoffOn503 bool
+
+	// MaxLogAge drops entries whose timestamp is older than this instead of
+	// shipping logs Datadog would reject or that pollute current dashboards
+	MaxLogAge time.Duration
+
+	// MaxErrorBodyBytes bounds the part of a failed response body captured
+	// for error messages and ResponseInfo.ErrorBody (default 512, negative
+	// disables capture)
+	MaxErrorBodyBytes int
+
+	// CorrelationHeader, when set, names a request header carrying an ID
+	// unique to each flush
+	CorrelationHeader string
+
+	// CorrelationAttribute, when set, stamps the flush ID on every entry
+	// under this attribute name
+	CorrelationAttribute string
+
+	// CorrelationIDGenerator produces flush IDs (default: random UUID)
+	CorrelationIDGenerator func() string
+
+	// Profiles holds named per-environment site/key/tag settings
+	Profiles map[string]Profile
+
+	// ActiveProfile selects an entry of Profiles; when empty the DD_PROFILE
+	// environment variable is used
+	ActiveProfile string
+
+	// ExcludeFields lists attributes that are never shipped. Entries are
+	// exact names or glob patterns such as "internal.*"
+	ExcludeFields []string
+
+	// StrictMode makes WriteRecord return a *ValidationError for records
+	// that would otherwise be fixed silently (a message over both
+	// LargeEntryBytes and MaxMessageBytes, an oversized value, control
+	// characters under SanitizeMessages, unsupported value types)
+	// and drops them, so logging bugs surface in development
+	StrictMode bool
+
+	// UnsupportedFieldPolicy controls attribute values that cannot be encoded
+	// as JSON: stringify (default), drop, or reject the entry with an error
+	UnsupportedFieldPolicy FieldPolicy
+
+	// DefaultFields are attributes added to every entry; record fields with
+	// the same key take precedence. Unlike Tags they are indexed attributes
+	DefaultFields map[string]any
+
+	// IncludeUptime stamps every entry with "uptime_ms", the milliseconds
+	// since New, to spot post-restart bursts
+	IncludeUptime bool
+
+	// Transforms are applied in order to every entry once it is fully
+	// built, after field exclusion and truncation. A transform may edit
+	// the entry freely or call Drop to discard it, which skips the rest.
+	// They run synchronously in WriteRecord, so keep them cheap.
+	Transforms []func(*LogEntry)
+
+	// SanitizeMessages rewrites newlines, tabs and other control
+	// characters in messages so every entry is a single line
+	SanitizeMessages bool
+
+	// SanitizeMode selects escaping (default) or space substitution for
+	// SanitizeMessages
+	SanitizeMode SanitizeMode
+
+	// PreserveOriginalMessage keeps the unsanitized message in the
+	// "original_message" attribute when SanitizeMessages changed it
+	PreserveOriginalMessage bool
+
+	// EmitEntryChecksum stamps every entry with "dd.checksum", a hex hash
+	// of the JSON encoding of its message and attributes, for end-to-end
+	// integrity checks. It is computed when the entry is encoded, so it
+	// covers every attribute sent, including the correlation ID and
+	// "dd.repeat_count", but not "dd.checksum" itself.
+	EmitEntryChecksum bool
+
+	// ChecksumHash supplies the hash for EmitEntryChecksum (default:
+	// SHA-256)
+	ChecksumHash func() hash.Hash
+
+	// ChecksumBytes truncates the checksum to this many bytes (default: 16)
+	ChecksumBytes int
+
+	// EmitLogID stamps every entry with a unique "dd.log_id" for
+	// deduplication and for referencing single log lines
+	EmitLogID bool
+
+	// LogIDGenerator replaces the default log ID generator (a random
+	// per-writer node ID plus an atomic sequence); it must be safe for
+	// concurrent use
+	LogIDGenerator func() string
+
+	// IncludeBuildInfo adds "git.commit.sha", "git.branch" and "build.time"
+	// from SetBuildInfo, or the binary's embedded VCS revision and time
+	IncludeBuildInfo bool
+
+	// EmitISOTimestamp adds "dd.timestamp_iso", the entry timestamp as
+	// ISO 8601 UTC text, next to the numeric timestamp
+	EmitISOTimestamp bool
+
+	// IncludeOriginalLevel adds the iris level name as "logger.level"
+	// next to the mapped status
+	IncludeOriginalLevel bool
+
 	// Additional tags to attach to all logs
 	Tags map[string]string
 
+	// Team is always sent as the "team" tag, for Datadog ownership and
+	// service catalog features; it overrides a "team" entry in Tags
+	Team string
+
+	// RequireTeamTag makes New warn through OnError when neither Team nor
+	// a "team" tag is configured
+	RequireTeamTag bool
+
+	// InheritAgentEnv fills empty Environment, Service and Version fields
+	// from DD_ENV, DD_SERVICE and DD_VERSION, and merges DD_TAGS into Tags
+	InheritAgentEnv bool
+
+	// ResourceAttributes are OpenTelemetry resource attributes translated to
+	// Datadog reserved attributes and tags (e.g. k8s.pod.name -> pod_name)
+	ResourceAttributes map[string]string
+
+	// RuntimeStatsInterval periodically emits an info entry with Go runtime
+	// statistics (goroutines, heap, GC pauses), tagged origin:runtime_stats
+	// and subject to the same attribute rules and Transforms as records
+	// (0 = disabled)
+	RuntimeStatsInterval time.Duration
+
 	// BatchSize is the maximum number of log entries to batch before sending
 	BatchSize int
 
-	// FlushInterval is the maximum time to wait before flushing incomplete batches
+	// FlushInterval is the maximum time to wait before flushing incomplete
+	// batches (default: 1s, minimum: 10ms)
 	FlushInterval time.Duration
 
+	// MinFlushInterval is the minimum spacing between size-triggered
+	// flushes; a full batch arriving sooner is held and flushed once the
+	// interval has elapsed (0 = flush immediately)
+	MinFlushInterval time.Duration
+
+	// MaxSplitConcurrency bounds how many sub-batches of one flush are sent
+	// at once when a flush exceeds the intake's 1000 entries per request
+	// (default: 4)
+	MaxSplitConcurrency int
+
+	// OnDropBatch receives the entries of a request that failed after all
+	// retries, e.g. to spool them elsewhere
+	OnDropBatch func(entries []LogEntry, err error)
+
+	// FlushAtBytes flushes once the estimated size of buffered entries
+	// reaches this many bytes, in addition to BatchSize. Sizes are estimated
+	// on append without marshaling and lean high, so flushes come slightly
+	// early rather than late. (0 = count-based only)
+	FlushAtBytes int
+
+	// MaxBufferBytes bounds the estimated size of buffered entries, so an
+	// outage cannot grow memory without limit (0 = unbounded)
+	MaxBufferBytes int
+
+	// BufferPolicy selects what is dropped at MaxBufferBytes: the oldest
+	// entries (default), the new entry, or the new entry with ErrBufferFull
+	BufferPolicy BufferPolicy
+
+	// DoubleBuffer swaps two preallocated buffers on flush instead of
+	// copying the buffer, removing the per-flush allocation
+	DoubleBuffer bool
+
+	// SortBatchByTime sorts each batch by timestamp before sending, so
+	// events written concurrently reach Datadog in logical order; entries
+	// with equal timestamps keep their buffer order
+	SortBatchByTime bool
+
+	// InitialBufferCapacity is the number of entries buffers are allocated
+	// for, so writers whose buffer regularly outgrows BatchSize (deferred
+	// flushes, cooldowns) do not regrow it after every flush. Buffers that
+	// grew past four times this capacity are released rather than reused.
+	// (default: BatchSize)
+	InitialBufferCapacity int
+
+	// MaxBufferAge bounds how long an entry may sit in the buffer: the
+	// first entry written to an empty buffer arms a one-shot flush after
+	// this duration. Idle intervals never produce a request. (0 = disabled)
+	MaxBufferAge time.Duration
+
+	// AlignFlushToWallClock fires timed flushes on wall-clock multiples of
+	// FlushInterval instead of relative to writer start
+	AlignFlushToWallClock bool
+
+	// LargeEntryBytes is the message size above which an entry is sent in
+	// its own request (default: 256KB)
+	LargeEntryBytes int
+
+	// MaxFieldValueBytes truncates string attribute values longer than
+	// this many bytes and drops trailing elements of larger arrays;
+	// original lengths are reported in the "truncated_fields" attribute
+	// (0 = unlimited)
+	MaxFieldValueBytes int
+
+	// MaxMessageBytes truncates messages of isolated large entries to this
+	// size, UTF-8 safe (default: 1MB, the Datadog per-log limit)
+	MaxMessageBytes int
+
 	// Timeout for HTTP requests to Datadog
 	Timeout time.Duration
 
+	// HTTPClient replaces the writer's HTTP client; the transport settings
+	// below are ignored when it is set
+	HTTPClient *http.Client
+
+	// MaxIdleConns limits idle connections across all hosts (default: 100)
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits idle connections to the intake host, kept
+	// high so bursts reuse connections instead of handshaking (default: 32)
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout closes connections idle for longer (default: 90s)
+	IdleConnTimeout time.Duration
+
+	// AdaptiveTimeout scales the per-request deadline with the batch size:
+	// Timeout + TimeoutPerEntry*entries, capped at MaxTimeout
+	AdaptiveTimeout bool
+
+	// TimeoutPerEntry is the deadline added per entry with AdaptiveTimeout
+	// (default: 10ms)
+	TimeoutPerEntry time.Duration
+
+	// MaxTimeout caps the adaptive deadline (default: 6x Timeout)
+	MaxTimeout time.Duration
+
+	// MaxLifetimeRequests caps the intake requests (retries included) a
+	// writer makes before Close, each attempt reserved before it is sent;
+	// once reached, entries stay buffered and are sent only by Close.
+	// Meant for short-lived jobs (0 = unlimited).
+	MaxLifetimeRequests int
+
+	// MaxConcurrentRequests bounds the number of simultaneous intake
+	// requests across all flushing goroutines (0 = unlimited)
+	MaxConcurrentRequests int
+
+	// AcquireTimeout bounds how long a send waits for a free request slot
+	// when MaxConcurrentRequests is reached (default: Timeout)
+	AcquireTimeout time.Duration
+
+	// ResolveHostOnStart makes New look up the intake hostname and fail if
+	// it does not resolve, catching Site typos at startup
+	ResolveHostOnStart bool
+
+	// DrainTimeout bounds how long Close waits for the final flush; on
+	// expiry Close returns ErrDrainTimeout while the flush continues in
+	// the background (0 = wait for the flush to finish, cooldowns
+	// included). It also bounds how long the final flush waits out a
+	// Retry-After or rate-limit cooldown; batches paused longer fail with
+	// ErrCooldown.
+	DrainTimeout time.Duration
+
+	// CloseRetryDelay replaces RetryDelay, and GatewayBackoff, for the
+	// final flush, so short shutdown windows are not spent backing off
+	// (0 = RetryDelay, negative = retry immediately)
+	CloseRetryDelay time.Duration
+
+	// CloseMaxRetries replaces MaxRetries for the final flush
+	// (0 = MaxRetries, negative = no retries)
+	CloseMaxRetries int
+
+	// BackgroundCloseRetry keeps retrying batches that failed after Close
+	// with a retryable error on a detached goroutine for up to this long
+	// (0 = drop them), reporting each batch once when it gives up. It only
+	// helps if the process stays alive after Close returns.
+	BackgroundCloseRetry time.Duration
+
+	// Warmup issues a HEAD request to the intake in New() so DNS, TCP and
+	// TLS setup happen before the first batch (failures go to OnError)
+	Warmup bool
+
 	// OnError is an optional callback for handling errors
 	OnError func(error)
 
+	// OnResponse is an optional callback invoked for every intake response,
+	// including the Datadog request ID when one is returned
+	OnResponse func(ResponseInfo)
+
 	// MaxRetries is the number of retry attempts for failed requests
 	MaxRetries int
 
 	// RetryDelay is the delay between retry attempts
 	RetryDelay time.Duration
 
+	// RetryBudgetRatio bounds retries across all batches to this fraction of
+	// requests (e.g. 0.1 = 10%); when exhausted, failures are not retried.
+	// Zero disables the budget.
+	RetryBudgetRatio float64
+
+	// RetryBudgetBurst is the number of retries available before the ratio
+	// applies (default: 10)
+	RetryBudgetBurst int
+
 	// EnableCompression enables gzip compression for HTTP requests to reduce bandwidth
 	EnableCompression bool
+
+	// Serializer encodes request bodies, e.g. NDJSONSerializer or an
+	// adapter for a faster JSON library (default: JSONSerializer)
+	Serializer Serializer
+
+	// MinimalPayload shrinks intake payloads by leaving out "status" when it
+	// is info, which Datadog assumes for logs without one, and every
+	// attribute equal to its PayloadDefaults value
+	MinimalPayload bool
+
+	// PayloadDefaults maps attribute names (standard ones such as
+	// "ddsource", or custom fields) to values MinimalPayload omits. Only
+	// list attributes whose absence your Datadog pipelines and queries
+	// treat like the default; "timestamp", "message" and "status" are
+	// rejected.
+	PayloadDefaults map[string]any
+
+	// PersistentCompressor reuses one gzip encoder for every batch,
+	// resetting it in between, instead of creating one per flush.
+	// Concurrent flushes take turns on the shared encoder.
+	PersistentCompressor bool
+
+	// EmitEventsAboveLevel additionally posts records at or above this
+	// level to the Datadog Events API, so they reach the event stream and
+	// monitors (e.g. iris.Error). Events are posted by a fixed set of
+	// workers from a bounded queue, and dropped when the queue is full.
+	// Levels at or below iris.Info disable it.
+	EmitEventsAboveLevel iris.Level
+
+	// WALDir enables a write-ahead log: every buffered entry is appended
+	// to a segment file in this directory and removed once Datadog
+	// accepted it, and entries left by a crash are re-sent on the next
+	// start. Each write then costs an encode and a file append under the
+	// buffer lock (plus an fsync with WALSync).
+	WALDir string
+
+	// WALSegmentBytes is the size at which WAL segments rotate
+	// (default: 16MiB)
+	WALSegmentBytes int
+
+	// WALMaxBytes bounds the WAL; the oldest segments are discarded past
+	// it (default: 256MiB)
+	WALMaxBytes int
+
+	// WALSync fsyncs every WAL append, trading throughput for surviving
+	// power loss as well as process crashes
+	WALSync bool
+
+	// TagsField names a record field holding per-record tags in ddtags
+	// form ("key:value,key2:value2"), merged with Tags
+	TagsField string
+
+	// TagMergePolicy resolves a TagsField key that is also a static tag:
+	// TagDynamicWins (default), TagStaticWins or TagKeepBoth
+	TagMergePolicy TagMergePolicy
+
+	// PartitionField names a record field (e.g. a tenant ID) whose value
+	// selects a separate buffer with its own batching and flush cadence,
+	// so one busy partition cannot delay the others. Request limits, the
+	// retry budget, cooldowns, health and tags stay writer-wide.
+	PartitionField string
+
+	// MaxPartitions bounds the partitions created for PartitionField;
+	// further values share the default buffer (default: 16)
+	MaxPartitions int
+
+	// AdditionalDestinations sends every qualifying entry to further
+	// Datadog orgs as well. Each destination batches, retries and fails
+	// independently; Flush and Close cover all of them.
+	AdditionalDestinations []DestinationConfig
+
+	// RespectRateLimitHeaders reads the X-RateLimit-* headers of intake
+	// responses and pauses sends until the period resets once fewer than
+	// 10% of requests remain
+	RespectRateLimitHeaders bool
+
+	// HTTPMethod is the method of intake requests, for gateways that expect
+	// PUT or PATCH (default: POST)
+	HTTPMethod string
+
+	// SignRequest is called on every intake request just before it is
+	// sent, with the final (compressed) body, to attach signature headers
+	// for authenticating gateways. An error aborts the send.
+	SignRequest func(req *http.Request, body []byte) error
+
+	// DebugRingSize keeps the last this many processed entries in memory
+	// for DebugHandler (0 = disabled)
+	DebugRingSize int
+
+	// CaptureMode records every intake request, and every Events API
+	// post, in memory instead of sending it; read them with
+	// CapturedBatches. Intended for tests, it
+	// makes APIKey optional and disables Warmup and ResolveHostOnStart.
+	CaptureMode bool
+
+	// OnCompress is called with the raw and compressed sizes of every batch
+	// that is actually compressed
+	OnCompress func(rawBytes, compressedBytes int)
+
+	// CompressionMaxInFlight skips compression while more than this many
+	// sends are in flight, trading bandwidth for CPU under load (0 = always compress)
+	CompressionMaxInFlight int
+
+	// DisableAfterConsecutiveFailures disables the writer after this many
+	// consecutive failed batches (0 = never disable)
+	DisableAfterConsecutiveFailures int
+
+	// ProbeInterval is how often a disabled writer probes Datadog to
+	// re-enable itself (default: 30s)
+	ProbeInterval time.Duration
+
+	// DNSFailureThreshold fails batches fast with ErrDNSUnresolvable for
+	// DNSCooldown after this many consecutive DNS resolution failures,
+	// instead of waiting out a lookup on every attempt (0 = disabled)
+	DNSFailureThreshold int
+
+	// DNSCooldown is how long the DNS circuit stays open before the host
+	// is resolved again (default: 30s)
+	DNSCooldown time.Duration
 }
 
 // LogEntry represents a single log entry for Datadog
 type LogEntry struct {
 	Timestamp int64          `json:"timestamp"`
-	Level     string         `json:"status"`
-	Message   string         `json:"message"`
+	Level     string         `json:"status,omitempty"`
+	Message   string         `json:"message,omitempty"`
 	Service   string         `json:"service,omitempty"`
 	Source    string         `json:"ddsource,omitempty"`
 	Tags      string         `json:"ddtags,omitempty"`
 	Hostname  string         `json:"hostname,omitempty"`
 	Env       string         `json:"env,omitempty"`
 	Version   string         `json:"version,omitempty"`
-	Fields    map[string]any `json:",inline"`
+	Fields    map[string]any `json:"-"` // Custom attributes, flattened by MarshalJSON
+
+	continuation bool   // Record was flagged with "dd.continuation"
+	dropped      bool   // A Config.Transforms function called Drop
+	walSegment   int64  // WAL segment holding the entry, 0 when not logged
+	walOrigin    walRef // WAL position the entry was first logged at
+	size         int    // Estimated encoded size while buffered
+}
+
+// Drop marks the entry to be discarded; for use in Config.Transforms
+func (e *LogEntry) Drop() {
+	e.dropped = true
+}
+
+// reservedKeys are the JSON keys of the fixed LogEntry attributes
+var reservedKeys = map[string]struct{}{
+	"timestamp": {},
+	"status":    {},
+	"message":   {},
+	"service":   {},
+	"ddsource":  {},
+	"ddtags":    {},
+	"hostname":  {},
+	"env":       {},
+	"version":   {},
+}
+
+// MarshalJSON emits the fixed attributes and the Fields map as a single
+// flat JSON object, so custom attributes land at the top level of the
+// Datadog log. Fixed attributes come first in declaration order, followed
+// by Fields in sorted key order, so the same entry always encodes to the
+// same bytes. Fixed attributes take precedence over Fields entries that
+// use a reserved key.
+func (e LogEntry) MarshalJSON() ([]byte, error) {
+	type plain LogEntry
+	fixed, err := json.Marshal(plain(e))
+	if err != nil || len(e.Fields) == 0 {
+		return fixed, err
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		if _, reserved := reservedKeys[key]; !reserved {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return fixed, nil
+	}
+	sort.Strings(keys)
+
+	// Extend {"fixed":...} to {"fixed":...,"key":value,...}
+	var buf bytes.Buffer
+	buf.Grow(len(fixed) + 32*len(keys))
+	buf.Write(fixed[:len(fixed)-1])
+	for _, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(e.Fields[key])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", key, err)
+		}
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// repeatCountKey is the attribute counting coalesced duplicate entries
+const repeatCountKey = "dd.repeat_count"
+
+// continuationKey marks a record whose message continues the previous entry
+const continuationKey = "dd.continuation"
+
+// defaultTimeoutPerEntry is the deadline added per entry with AdaptiveTimeout
+const defaultTimeoutPerEntry = 10 * time.Millisecond
+
+// flushCoalesceWindow is how soon after a flush a timed flush is skipped
+// as redundant
+const flushCoalesceWindow = 5 * time.Millisecond
+
+// minFlushInterval is the shortest FlushInterval New accepts; smaller
+// values would re-arm the flush timer in a tight loop
+const minFlushInterval = 10 * time.Millisecond
+
+// uptimeKey is the attribute holding milliseconds since the writer started
+const uptimeKey = "uptime_ms"
+
+// isoTimestampKey is the attribute holding the timestamp as ISO 8601 text
+const isoTimestampKey = "dd.timestamp_iso"
+
+// originalLevelKey is the attribute holding the iris level name
+const originalLevelKey = "logger.level"
+
+// timestampKey is the record field that overrides the entry timestamp
+const timestampKey = "timestamp"
+
+// DefaultHostnameFields mirrors the order in which Datadog resolves the
+// host of a log from its reserved attributes.
+var DefaultHostnameFields = []string{"host", "hostname", "syslog.hostname"}
+
+// New creates a new Datadog writer with the given configuration
+func New(config Config) (*Writer, error) {
+	return newWriter(config, nil)
+}
+
+// newWriter creates a writer, or with a parent, one of its partitions
+func newWriter(config Config, parent *Writer) (*Writer, error) {
+	if err := applyProfile(&config); err != nil {
+		return nil, err
+	}
+	if config.APIKey == "" && config.Output == OutputIntake && !config.CaptureMode {
+		return nil, fmt.Errorf("API key is required")
+	}
+	if config.SyslogAddress == "" && config.Output.isSyslog() {
+		return nil, fmt.Errorf("syslog address is required for %s output", config.Output)
+	}
+
+	// Set defaults
+	if config.Site == "" {
+		config.Site = "datadoghq.com"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+	if err := validatePayloadDefaults(config.PayloadDefaults); err != nil {
+		return nil, err
+	}
+	switch config.HTTPMethod = strings.ToUpper(config.HTTPMethod); config.HTTPMethod {
+	case "":
+		config.HTTPMethod = http.MethodPost
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return nil, fmt.Errorf("HTTPMethod %q cannot carry a request body: use POST, PUT or PATCH", config.HTTPMethod)
+	}
+	if config.InitialBufferCapacity <= 0 {
+		config.InitialBufferCapacity = config.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	} else if config.FlushInterval < minFlushInterval {
+		if config.OnError != nil {
+			config.OnError(fmt.Errorf("FlushInterval %v is below the %v minimum, using %v", config.FlushInterval, minFlushInterval, minFlushInterval))
+		}
+		config.FlushInterval = minFlushInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxSplitConcurrency <= 0 {
+		config.MaxSplitConcurrency = defaultMaxSplitConcurrency
+	}
+	if config.TimeoutPerEntry <= 0 {
+		config.TimeoutPerEntry = defaultTimeoutPerEntry
+	}
+	if config.MaxTimeout <= 0 {
+		config.MaxTimeout = 6 * config.Timeout
+	}
+	if config.MaxIdleConns <= 0 {
+		config.MaxIdleConns = 100
+	}
+	if config.MaxIdleConnsPerHost <= 0 {
+		config.MaxIdleConnsPerHost = 32
+	}
+	if config.IdleConnTimeout <= 0 {
+		config.IdleConnTimeout = 90 * time.Second
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 100 * time.Millisecond
+	}
+	if config.NoSource {
+		config.Source = ""
+	} else if config.Source == "" {
+		config.Source = "go"
+	}
+	if config.ChecksumBytes <= 0 {
+		config.ChecksumBytes = defaultChecksumBytes
+	}
+	if config.WALSegmentBytes <= 0 {
+		config.WALSegmentBytes = defaultWALSegmentBytes
+	}
+	if config.WALMaxBytes <= 0 {
+		config.WALMaxBytes = defaultWALMaxBytes
+	}
+	if config.PartitionField != "" && config.MaxPartitions <= 0 {
+		config.MaxPartitions = defaultMaxPartitions
+	}
+	if config.LargeEntryBytes <= 0 {
+		config.LargeEntryBytes = defaultLargeEntryBytes
+	}
+	if config.MaxMessageBytes <= 0 {
+		config.MaxMessageBytes = defaultMaxMessageBytes
+	}
+	if config.AcquireTimeout <= 0 {
+		config.AcquireTimeout = config.Timeout
+	}
+	if len(config.ServiceAttributeNames) == 0 {
+		config.ServiceAttributeNames = []string{"service"}
+	}
+	if config.DefaultLevelStatus == "" {
+		config.DefaultLevelStatus = "info"
+	}
+	if config.MaxErrorBodyBytes == 0 {
+		config.MaxErrorBodyBytes = defaultMaxErrorBodyBytes
+	}
+	if config.DNSCooldown <= 0 {
+		config.DNSCooldown = 30 * time.Second
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = 30 * time.Second
+	}
+	if config.OutputWriter == nil {
+		config.OutputWriter = os.Stdout
+	}
+	if err := applyDerivedTags(&config); err != nil {
+		return nil, err
+	}
+	derived, err := derivedTags(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Intake requests carry their own deadline (see requestTimeout), which
+	// may exceed Timeout with AdaptiveTimeout
+	client := config.HTTPClient
+	if client == nil {
+		clientTimeout := config.Timeout
+		if config.AdaptiveTimeout {
+			clientTimeout = max(config.Timeout, config.MaxTimeout)
+		}
+		client = &http.Client{
+			Timeout:   clientTimeout,
+			Transport: newTransport(config),
+		}
+	}
+
+	writer := &Writer{
+		config: config,
+		client: client,
+		buffer: make([]LogEntry, 0, config.InitialBufferCapacity),
+		done:   make(chan struct{}),
+		parent: parent,
+
+		derived:   derived,
+		startedAt: timecache.CachedTimeNano(),
+	}
+	writer.trace = &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				writer.stats.newConnections.Add(1)
+			}
+		},
+	}
+	if config.DoubleBuffer {
+		writer.spare = writer.newBuffer()
+	}
+	writer.routes = sortLevelRoutes(config.LevelRouting)
+	writer.ring = newDebugRing(config.DebugRingSize)
+	if config.EmitLogID {
+		writer.logIDs = newLogIDSource()
+	}
+	if len(config.OmitAttributes) > 0 {
+		writer.omit = make(map[string]bool, len(config.OmitAttributes))
+		for _, name := range config.OmitAttributes {
+			writer.omit[name] = true
+		}
+	}
+	writer.exclude = newFieldMatcher(config.ExcludeFields)
+	if len(config.AllowedServices) > 0 {
+		writer.allowedServices = make(map[string]bool, len(config.AllowedServices))
+		for _, service := range config.AllowedServices {
+			writer.allowedServices[service] = true
+		}
+	}
+	if config.MaxConcurrentRequests > 0 {
+		writer.slots = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	if config.RetryBudgetRatio > 0 {
+		writer.budget = newRetryBudget(config.RetryBudgetRatio, config.RetryBudgetBurst)
+	}
+	if parent != nil {
+		// Request slots, the retry budget and the debug ring stay
+		// writer-wide rather than multiplying per partition
+		writer.slots = parent.slots
+		writer.budget = parent.budget
+		writer.ring = parent.ring
+	}
+	writer.tags.Store(&tagSet{tags: config.Tags, ddtags: writer.buildTagsString()})
+
+	if config.RuntimeStatsInterval > 0 {
+		go runRuntimeStats(weak.Make(writer), config.RuntimeStatsInterval, writer.done)
+	}
+	var replay []string
+	if config.WALDir != "" && config.Output == OutputIntake {
+		l, paths, err := openWAL(config)
+		if err != nil {
+			return nil, err
+		}
+		writer.wal = l
+		replay = paths
+	}
+	if config.Output == OutputIntake {
+		if config.ResolveHostOnStart && !config.CaptureMode {
+			if err := writer.resolveIntakeHost(); err != nil {
+				return nil, err
+			}
+		}
+		if config.Warmup && !config.CaptureMode {
+			writer.warmup()
+		}
+		writer.guardAbandoned()
+		writer.startFlushTimer()
+	}
+	if config.Output.isSyslog() {
+		writer.connectSyslog()
+	}
+	if parent == nil && config.EmitEventsAboveLevel > iris.Info {
+		writer.startEventWorkers()
+	}
+	if len(replay) > 0 {
+		writer.replaying.Add(1)
+		go writer.replayWAL(replay)
+	}
+	if config.PartitionField != "" && writer.wal != nil {
+		writer.restorePartitions()
+	}
+	if len(config.AdditionalDestinations) > 0 {
+		destinations, err := newDestinations(config)
+		if err != nil {
+			_ = writer.Close()
+			return nil, err
+		}
+		writer.destinations = destinations
+	}
+	return writer, nil
+}
+
+// ErrWriterClosed is returned by WriteRecord after Close
+var ErrWriterClosed = errors.New("datadog writer is closed")
+
+// ErrLogTooOld is returned by WriteRecordAt for timestamps older than
+// Config.MaxLogAge
+var ErrLogTooOld = errors.New("log timestamp is older than MaxLogAge")
+
+// WriteRecord implements iris.SyncWriter
+func (w *Writer) WriteRecord(record *iris.Record) error {
+	w.fanOut(record, time.Time{})
+	return w.partitionFor(record).writeRecord(record, time.Time{})
+}
+
+// WriteRecordAt writes record with the timestamp at instead of the current
+// time or the record's own "timestamp" field, for replay and backfill
+// tooling. Records older than Config.MaxLogAge are dropped and reported
+// with ErrLogTooOld.
+func (w *Writer) WriteRecordAt(at time.Time, record *iris.Record) error {
+	w.fanOut(record, at)
+	return w.partitionFor(record).writeRecord(record, at)
+}
+
+// WriteRecords writes records in order. Each record takes the buffer lock
+// on its own, exactly like WriteRecord, so a large bulk call interleaves
+// with concurrent writers instead of holding the buffer, and flushes fire
+// as batches fill. Entries from one goroutine appear in call order;
+// entries from different goroutines may interleave. Every record is
+// attempted; failures are joined, with ValidationError.Index set to the
+// record's position.
+func (w *Writer) WriteRecords(records []*iris.Record) error {
+	var errs []error
+	for i, record := range records {
+		err := w.WriteRecord(record)
+		if err == nil {
+			continue
+		}
+		var validation *ValidationError
+		if errors.As(err, &validation) {
+			validation.Index = i
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// writeRecord builds and enqueues an entry; a non-zero at overrides the
+// entry timestamp
+func (w *Writer) writeRecord(record *iris.Record, at time.Time) error {
+	if w.root().disabled.Load() {
+		w.stats.dropped.Add(1)
+		return nil
+	}
+
+	if w.sampledOut(record.Level) {
+		w.stats.sampled.Add(1)
+		return nil
+	}
+	if w.rejectService(record) {
+		w.stats.servicesRejected.Add(1)
+		w.stats.dropped.Add(1)
+		return nil
+	}
+
+	entry := w.buildLogEntry(record)
+	if entry.dropped {
+		w.stats.transformDropped.Add(1)
+		w.stats.dropped.Add(1)
+		return nil
+	}
+	if !at.IsZero() {
+		entry.Timestamp = at.UnixMilli()
+	}
+	if w.config.EmitISOTimestamp {
+		entry.Fields[isoTimestampKey] = formatISOTimestamp(entry.Timestamp)
+	}
+	if w.config.JoinContinuations {
+		entry.continuation = isContinuation(record)
+	}
+	if w.expired(entry) {
+		w.stats.expired.Add(1)
+		w.stats.dropped.Add(1)
+		if !at.IsZero() {
+			return fmt.Errorf("%w: %s", ErrLogTooOld, at.Format(time.RFC3339))
+		}
+		return nil
+	}
+	if w.config.StrictMode {
+		if err := w.validateEntry(entry, 0); err != nil {
+			w.stats.invalid.Add(1)
+			w.stats.dropped.Add(1)
+			return err
+		}
+	}
+	if err := w.sanitizeFields(entry.Fields); err != nil {
+		w.stats.dropped.Add(1)
+		return err
+	}
+	if w.emitsEvent(record.Level) {
+		w.postEventAsync(entry)
+	}
+	w.ring.add(entry)
+	return w.enqueue(entry)
+}
+
+// formatISOTimestamp renders a Unix millisecond timestamp as ISO 8601 UTC
+// with millisecond precision, the same instant as the numeric timestamp
+func formatISOTimestamp(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// expired reports whether entry is older than Config.MaxLogAge
+func (w *Writer) expired(entry LogEntry) bool {
+	if w.config.MaxLogAge <= 0 {
+		return false
+	}
+	age := time.Duration(timecache.CachedTimeNano()/1000000-entry.Timestamp) * time.Millisecond
+	return age > w.config.MaxLogAge
+}
+
+// enqueue hands a built entry to the configured output, buffering it for
+// the intake and flushing when the batch is full.
+func (w *Writer) enqueue(entry LogEntry) error {
+	switch w.config.Output {
+	case OutputStdout, OutputSyslogTCP, OutputSyslogUDP:
+		if w.closed.Load() {
+			w.stats.dropped.Add(1)
+			return ErrWriterClosed
+		}
+		if w.config.Output == OutputStdout {
+			return w.writeLine(entry)
+		}
+		return w.writeSyslog(entry)
+	}
+
+	w.mutex.Lock()
+	// Checked under mutex: Close marks the writer closed before its final
+	// flush takes the mutex, so an entry is either in that flush or rejected
+	if w.closed.Load() {
+		w.mutex.Unlock()
+		w.stats.dropped.Add(1)
+		return ErrWriterClosed
+	}
+	if entry.continuation && w.joinContinuation(entry) {
+		w.mutex.Unlock()
+		return nil
+	}
+	if w.config.CoalesceDuplicates && w.coalesce(entry) {
+		w.mutex.Unlock()
+		return nil
+	}
+	entry.size = entrySize(entry)
+	ok, crossed, err := w.makeRoom(entry.size)
+	if !ok {
+		w.mutex.Unlock()
+		if crossed {
+			w.reportBufferFull()
+		}
+		return err
+	}
+	if w.wal != nil {
+		w.wal.append(&entry)
+	}
+	if len(w.buffer) == 0 && w.config.MaxBufferAge > 0 {
+		w.ageTimer = time.AfterFunc(w.config.MaxBufferAge, func() { _ = w.flush() })
+	}
+	w.buffer = append(w.buffer, entry)
+	w.bufferBytes += entry.size
+	w.noteBuffered(entry.Timestamp)
+	shouldFlush := w.batchFull() && !w.deferFlush()
+	w.mutex.Unlock()
+
+	if crossed {
+		w.reportBufferFull()
+	}
+	if shouldFlush {
+		return w.flushIf(w.batchFull)
+	}
+	return nil
+}
+
+// batchFull reports whether the buffer holds a full batch, by entry count
+// or by the estimated size against Config.FlushAtBytes. Must be called
+// with w.mutex held.
+func (w *Writer) batchFull() bool {
+	if w.config.FlushAtBytes > 0 && w.bufferBytes >= w.config.FlushAtBytes {
+		return true
+	}
+	return len(w.buffer) >= w.config.BatchSize
+}
+
+// timedFlushDue reports whether a timed flush should send: not when
+// another flush emptied the buffer within flushCoalesceWindow, unless a
+// full batch has built up since. Must be called with w.mutex held.
+func (w *Writer) timedFlushDue() bool {
+	return w.batchFull() || time.Since(w.lastFlush) >= flushCoalesceWindow
+}
+
+// deferFlush reports whether a size-triggered flush must wait because the
+// previous flush was less than Config.MinFlushInterval ago. The deferred
+// flush is scheduled once; later triggers join it. The timer clears
+// itself when it fires, whatever the flush then does, so an empty buffer
+// or a cooldown cannot leave a stale timer holding back later flushes.
+// Must be called with w.mutex held.
+func (w *Writer) deferFlush() bool {
+	if w.config.MinFlushInterval <= 0 {
+		return false
+	}
+	wait := w.config.MinFlushInterval - time.Since(w.lastFlush)
+	if wait <= 0 {
+		return false
+	}
+	w.stats.flushesCoalesced.Add(1)
+	if w.deferTimer == nil {
+		var timer *time.Timer
+		timer = time.AfterFunc(wait, func() {
+			w.mutex.Lock()
+			if w.deferTimer == timer {
+				w.deferTimer = nil
+			}
+			w.mutex.Unlock()
+			_ = w.flush()
+		})
+		w.deferTimer = timer
+	}
+	return true
+}
+
+// coalesce folds entry into the last buffered entry when both carry the
+// same message and level, counting repeats in the "dd.repeat_count"
+// attribute. Must be called with w.mutex held.
+func (w *Writer) coalesce(entry LogEntry) bool {
+	if len(w.buffer) == 0 {
+		return false
+	}
+
+	last := &w.buffer[len(w.buffer)-1]
+	if last.Message != entry.Message || last.Level != entry.Level {
+		return false
+	}
+	if window := w.config.CoalesceWindow; window > 0 && entry.Timestamp-last.Timestamp > window.Milliseconds() {
+		return false
+	}
+
+	if last.Fields == nil {
+		last.Fields = make(map[string]any)
+	}
+	var count int
+	switch repeats := last.Fields[repeatCountKey].(type) {
+	case int:
+		count = repeats
+	case json.Number: // Replayed from the WAL
+		n, _ := repeats.Int64()
+		count = int(n)
+	}
+	if count == 0 {
+		count = 1
+	}
+	last.Fields[repeatCountKey] = count + 1
+	w.wal.rewrite(last)
+	w.stats.coalesced.Add(1)
+	return true
+}
+
+// Flush sends all buffered logs immediately
+func (w *Writer) Flush() error {
+	if len(w.destinations) == 0 && w.config.PartitionField == "" {
+		return w.flush()
+	}
+	return errors.Join(w.flush(), w.flushPartitions(), w.flushDestinations())
+}
+
+// joinContinuation appends a continuation entry's message to the last
+// buffered entry. A continuation arriving after its head was flushed has
+// nothing to join and is buffered as an entry of its own. Must be called
+// with mutex held.
+func (w *Writer) joinContinuation(entry LogEntry) bool {
+	if len(w.buffer) == 0 {
+		return false
+	}
+
+	last := &w.buffer[len(w.buffer)-1]
+	last.Message += "\n" + entry.Message
+	last.size += len(entry.Message) + 1
+	w.bufferBytes += len(entry.Message) + 1
+	w.wal.rewrite(last)
+	w.stats.joined.Add(1)
+	return true
+}
+
+// isContinuation reports whether the record carries "dd.continuation": true
+func isContinuation(record *iris.Record) bool {
+	field, ok := lookupField(record, continuationKey)
+	return ok && field.IsBool() && field.BoolValue()
+}
+
+// Close flushes remaining logs and shuts down the writer
+func (w *Writer) Close() error {
+	w.timerMutex.Lock()
+	w.stopFlushTimer()
+	if w.probeTimer != nil {
+		w.probeTimer.Stop()
+		w.probeTimer = nil
+	}
+	if w.config.DrainTimeout > 0 {
+		w.drainDeadline.Store(time.Now().Add(w.config.DrainTimeout).UnixNano())
+	}
+	w.closed.Store(true)
+	w.timerMutex.Unlock()
+
+	w.closeOnce.Do(func() { close(w.done) })
+
+	w.outputMutex.Lock()
+	w.closeSyslog()
+	w.outputMutex.Unlock()
+	w.stopEventWorkers()
+	w.replaying.Wait()
+	defer w.wal.close()
+
+	if len(w.destinations) == 0 && w.config.PartitionField == "" {
+		return w.drain()
+	}
+	return errors.Join(w.drain(), w.closePartitions(), closeDestinations(w.destinations))
+}
+
+func (w *Writer) buildLogEntry(record *iris.Record) LogEntry {
+	return w.buildLogEntryWithTags(record, w.resolveTags(record))
+}
+
+// buildLogEntryWithTags builds the entry for record with the given ddtags,
+// applying every attribute rule and Config.Transforms
+func (w *Writer) buildLogEntryWithTags(record *iris.Record, tags string) LogEntry {
+	entry := LogEntry{
+		Timestamp: resolveTimestamp(record),
+		Level:     w.levelStatus(record.Level),
+		Message:   w.resolveMessage(record),
+		Service:   w.resolveService(record),
+		Source:    w.resolveSource(record),
+		Hostname:  w.resolveHostname(record),
+		Env:       w.config.Environment,
+		Version:   w.config.Version,
+		Tags:      tags,
+		Fields:    make(map[string]any, len(w.config.DefaultFields)+record.FieldCount()),
+	}
+	for key, value := range w.config.DefaultFields {
+		entry.Fields[key] = value
+	}
+	recordFields(record, entry.Fields)
+	if w.config.IncludeUptime {
+		entry.Fields[uptimeKey] = (timecache.CachedTimeNano() - w.startedAt) / int64(time.Millisecond)
+	}
+	if w.config.IncludeOriginalLevel {
+		entry.Fields[originalLevelKey] = record.Level.String()
+	}
+	if w.config.IncludeBuildInfo {
+		applyBuildInfo(entry.Fields)
+	}
+	if w.config.EmitLogID {
+		entry.Fields[logIDKey] = w.logID()
+	}
+	if len(w.routes) > 0 {
+		w.applyLevelRoute(record.Level, &entry)
+	}
+	if w.config.SanitizeMessages && !w.config.StrictMode {
+		w.applySanitize(&entry)
+	}
+	if w.config.ClientIPField != "" {
+		w.applyClientIP(record, entry.Fields)
+	}
+	w.applyServiceAttributes(&entry)
+	if w.exclude != nil {
+		w.excludeFields(entry.Fields)
+	}
+	if len(w.omit) > 0 {
+		w.omitAttributes(&entry)
+	}
+	if w.config.MaxFieldValueBytes > 0 && !w.config.StrictMode {
+		w.truncateFieldValues(entry.Fields)
+	}
+	for _, transform := range w.config.Transforms {
+		transform(&entry)
+		if entry.dropped {
+			break
+		}
+	}
+
+	return entry
 }
 
-// New creates a new Datadog writer with the given configuration
-func New(config Config) (*Writer, error) {
-	if config.APIKey == "" {
-		return nil, fmt.Errorf("API key is required")
+// applyClientIP copies the Config.ClientIPField value to
+// network.client.ip when it parses as an IP address. Other network
+// attributes already present are kept.
+func (w *Writer) applyClientIP(record *iris.Record, fields map[string]any) {
+	value, ok := lookupString(record, w.config.ClientIPField)
+	if !ok {
+		return
+	}
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		w.stats.invalidClientIPs.Add(1)
+		return
 	}
 
-	// Set defaults
-	if config.Site == "" {
-		config.Site = "datadoghq.com"
+	network := make(map[string]any)
+	if existing, ok := fields["network"].(map[string]any); ok {
+		for key, value := range existing {
+			network[key] = value
+		}
 	}
-	if config.BatchSize <= 0 {
-		config.BatchSize = 1000
+	client := make(map[string]any)
+	if existing, ok := network["client"].(map[string]any); ok {
+		for key, value := range existing {
+			client[key] = value
+		}
 	}
-	if config.FlushInterval <= 0 {
-		config.FlushInterval = time.Second
+	client["ip"] = ip.String()
+	network["client"] = client
+	fields["network"] = network
+}
+
+// omitAttributes clears the standard attributes listed in Config.OmitAttributes.
+func (w *Writer) omitAttributes(entry *LogEntry) {
+	if w.omit["service"] {
+		entry.Service = ""
 	}
-	if config.Timeout <= 0 {
-		config.Timeout = 10 * time.Second
+	if w.omit["ddsource"] {
+		entry.Source = ""
 	}
-	if config.MaxRetries <= 0 {
-		config.MaxRetries = 3
+	if w.omit["ddtags"] {
+		entry.Tags = ""
 	}
-	if config.RetryDelay <= 0 {
-		config.RetryDelay = 100 * time.Millisecond
+	if w.omit["hostname"] {
+		entry.Hostname = ""
 	}
-	if config.Source == "" {
-		config.Source = "go"
+	if w.omit["env"] {
+		entry.Env = ""
 	}
+	if w.omit["version"] {
+		entry.Version = ""
+	}
+}
 
-	client := &http.Client{
-		Timeout: config.Timeout,
+// applyServiceAttributes emits the service under Config.ServiceAttributeNames.
+// The reserved "service" attribute is only kept when it is listed.
+func (w *Writer) applyServiceAttributes(entry *LogEntry) {
+	names := w.config.ServiceAttributeNames
+	if entry.Service == "" || len(names) == 0 || (len(names) == 1 && names[0] == "service") {
+		return
 	}
 
-	writer := &Writer{
-		config: config,
-		client: client,
-		buffer: make([]LogEntry, 0, config.BatchSize),
+	service := entry.Service
+	entry.Service = ""
+	for _, name := range names {
+		if name == "service" {
+			entry.Service = service
+		} else {
+			entry.Fields[name] = service
+		}
 	}
+}
 
-	writer.startFlushTimer()
-	return writer, nil
+// resolveSource returns the per-record ddsource from Config.SourceField
+// or, with SourceFromLoggerName, the logger name, falling back to
+// Config.Source.
+func (w *Writer) resolveSource(record *iris.Record) string {
+	if w.config.SourceField != "" {
+		if value, ok := lookupString(record, w.config.SourceField); ok && value != "" {
+			return value
+		}
+	}
+	if w.config.SourceFromLoggerName && record.Logger != "" {
+		if source := normalizeSource(record.Logger); source != "" {
+			return source
+		}
+	}
+	return w.config.Source
 }
 
-// WriteRecord implements iris.SyncWriter
-func (w *Writer) WriteRecord(record *iris.Record) error {
-	entry := w.buildLogEntry(record)
+// normalizeSource turns a logger name such as "Billing API" into a
+// ddsource value ("billing_api"): lower case, with characters outside
+// [a-z0-9._-] replaced by underscores.
+func normalizeSource(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
 
-	w.mutex.Lock()
-	w.buffer = append(w.buffer, entry)
-	shouldFlush := len(w.buffer) >= w.config.BatchSize
-	w.mutex.Unlock()
+// resolveHostname returns the first non-empty string value found in the
+// record for Config.HostnameFields, falling back to Config.Hostname.
+func (w *Writer) resolveHostname(record *iris.Record) string {
+	for _, key := range w.config.HostnameFields {
+		if value, ok := lookupString(record, key); ok && value != "" {
+			return value
+		}
+	}
+	return w.config.Hostname
+}
 
-	if shouldFlush {
-		return w.flush()
+// resolveMessage returns the record message, synthesizing it from
+// Config.MessageFromField when the record carries no message. An empty
+// result is omitted from the payload so structured-only events are not
+// sent with a blank message.
+func (w *Writer) resolveMessage(record *iris.Record) string {
+	if record.Msg != "" || w.config.MessageFromField == "" {
+		return record.Msg
 	}
-	return nil
+	if field, ok := lookupField(record, w.config.MessageFromField); ok {
+		return fieldText(field)
+	}
+	return ""
 }
 
-// Close flushes remaining logs and shuts down the writer
-func (w *Writer) Close() error {
-	w.timerMutex.Lock()
-	if w.timer != nil {
-		w.timer.Stop()
-		w.timer = nil
+// fieldText renders a field value as plain text.
+func fieldText(field iris.Field) string {
+	switch {
+	case field.IsString():
+		return field.Str
+	case field.IsInt():
+		return strconv.FormatInt(field.I64, 10)
+	case field.IsUint():
+		return strconv.FormatUint(field.U64, 10)
+	case field.IsFloat():
+		return strconv.FormatFloat(field.F64, 'g', -1, 64)
+	case field.IsBool():
+		return strconv.FormatBool(field.BoolValue())
+	case field.IsDuration():
+		return field.DurationValue().String()
+	case field.IsTime():
+		return field.TimeValue().UTC().Format(time.RFC3339Nano)
+	case field.IsBytes():
+		return string(field.B)
+	case field.Obj != nil:
+		return fmt.Sprint(field.Obj)
+	default:
+		return field.Str
 	}
-	w.closed = true
-	w.timerMutex.Unlock()
+}
 
-	return w.flush()
+// resolveTimestamp returns the entry timestamp in milliseconds: the time
+// field named "timestamp" when the record carries one (replayed or
+// forwarded events), otherwise the current time.
+func resolveTimestamp(record *iris.Record) int64 {
+	if field, ok := lookupField(record, timestampKey); ok && field.IsTime() {
+		return field.TimeValue().UnixMilli()
+	}
+	return timecache.CachedTimeNano() / 1000000 // Convert to milliseconds
 }
 
-func (w *Writer) buildLogEntry(record *iris.Record) LogEntry {
-	entry := LogEntry{
-		Timestamp: timecache.CachedTimeNano() / 1000000, // Convert to milliseconds
-		Level:     mapLevel(record.Level),
-		Message:   record.Msg,
-		Service:   w.config.Service,
-		Source:    w.config.Source,
-		Hostname:  w.config.Hostname,
-		Env:       w.config.Environment,
-		Version:   w.config.Version,
-		Fields:    make(map[string]any),
+// lookupField returns the first field in the record with the given key.
+func lookupField(record *iris.Record, key string) (iris.Field, bool) {
+	for i := 0; i < record.FieldCount(); i++ {
+		if field := record.GetField(i); field.K == key {
+			return field, true
+		}
 	}
+	return iris.Field{}, false
+}
 
-	// Build tags string
-	if len(w.config.Tags) > 0 {
-		entry.Tags = w.buildTagsString()
+// lookupString returns the value of a string field in the record.
+func lookupString(record *iris.Record, key string) (string, bool) {
+	field, ok := lookupField(record, key)
+	if !ok || !field.IsString() {
+		return "", false
 	}
+	return field.Str, true
+}
 
-	return entry
+// UpdateTags replaces the tags attached to all subsequent logs. They are
+// merged with the tags New derived from the configuration with the same
+// precedence: derived tags fill the keys tags lacks, while the active
+// profile's tags and Team override. The new ddtags string is computed once
+// and swapped in atomically, so logging goroutines never contend on a
+// lock to read it.
+func (w *Writer) UpdateTags(tags map[string]string) {
+	profile := w.config.Profiles[w.config.ActiveProfile].Tags
+	merged := make(map[string]string, len(w.derived)+len(tags)+len(profile)+1)
+	for _, source := range []map[string]string{w.derived, tags, profile} {
+		for key, value := range source {
+			merged[key] = value
+		}
+	}
+	if w.config.Team != "" {
+		merged[teamTag] = w.config.Team
+	}
+	w.tags.Store(&tagSet{tags: merged, ddtags: formatTags(merged)})
+}
+
+// currentTags returns the active tag set. Partitions use their parent's,
+// so UpdateTags applies to them too.
+func (w *Writer) currentTags() *tagSet {
+	if set, ok := w.root().tags.Load().(*tagSet); ok {
+		return set
+	}
+	return &tagSet{}
 }
 
+// buildTagsString renders Config.Tags as a Datadog ddtags string.
 func (w *Writer) buildTagsString() string {
-	if len(w.config.Tags) == 0 {
+	return formatTags(w.config.Tags)
+}
+
+// formatTags renders a tag map as a Datadog ddtags string.
+// Keys are sorted so the output is deterministic; the builder is
+// pre-sized so the whole string is produced with a single allocation.
+func formatTags(tags map[string]string) string {
+	if len(tags) == 0 {
 		return ""
 	}
 
-	var tags []string
-	for key, value := range w.config.Tags {
-		tags = append(tags, fmt.Sprintf("%s:%s", key, value))
+	keys := make([]string, 0, len(tags))
+	size := 0
+	for key, value := range tags {
+		keys = append(keys, key)
+		size += len(key) + len(value) + 2 // ':' and ','
 	}
+	sort.Strings(keys)
 
-	result := ""
-	for i, tag := range tags {
+	var b strings.Builder
+	b.Grow(size)
+	for i, key := range keys {
 		if i > 0 {
-			result += ","
+			b.WriteByte(',')
+		}
+		b.WriteString(key)
+		if value := tags[key]; value != "" {
+			b.WriteByte(':')
+			b.WriteString(value)
 		}
-		result += tag
 	}
-	return result
+	return b.String()
 }
 
 func (w *Writer) flush() error {
+	return w.flushIf(nil)
+}
+
+// flushIf flushes when due, a check made under the buffer lock, so a
+// trigger that raced with another flush does not send a near-empty batch
+// right behind it. A nil due always flushes.
+func (w *Writer) flushIf(due func() bool) error {
+	// Close's final flush does not defer to a cooldown: sendToDatadog
+	// waits it out within DrainTimeout or fails the batch
+	if (w.inCooldown() && !w.closed.Load()) || w.lifetimeExhausted() {
+		return nil
+	}
+
 	w.mutex.Lock()
 	if len(w.buffer) == 0 {
 		w.mutex.Unlock()
 		return nil
 	}
+	if due != nil && !due() {
+		w.mutex.Unlock()
+		w.stats.flushesSkipped.Add(1)
+		return nil
+	}
+
+	entries := w.takeBuffer()
+	if w.ageTimer != nil {
+		w.ageTimer.Stop()
+		w.ageTimer = nil
+	}
+	if w.deferTimer != nil {
+		w.deferTimer.Stop()
+		w.deferTimer = nil
+	}
+	w.lastFlush = time.Now()
+	w.mutex.Unlock()
+
+	err := w.deliver(entries)
+	if w.config.DoubleBuffer {
+		w.releaseBuffer(entries)
+	}
+	return err
+}
+
+// lifetimeExhausted reports whether Config.MaxLifetimeRequests has been
+// used up, deferring every flush but Close's final one
+func (w *Writer) lifetimeExhausted() bool {
+	limit := w.config.MaxLifetimeRequests
+	return limit > 0 && w.root().stats.requests.Load() >= uint64(limit) && !w.closed.Load()
+}
+
+// reserveRequest counts one intake request against
+// Config.MaxLifetimeRequests, reporting false when none is left. Each
+// attempt reserves its own, so retries and the sub-batches of a split
+// flush cannot exceed the cap; sends made by Close are not capped.
+func (w *Writer) reserveRequest() bool {
+	// Counted on the parent, so MaxLifetimeRequests covers all partitions
+	requests := &w.root().stats.requests
+	limit := w.config.MaxLifetimeRequests
+	if limit <= 0 || w.closed.Load() {
+		requests.Add(1)
+		return true
+	}
+	for {
+		n := requests.Load()
+		if n >= uint64(limit) {
+			return false
+		}
+		if requests.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// Snapshot returns a copy of the currently buffered entries, including
+// those of every partition. Nothing is sent to Datadog and the buffers
+// are left unchanged.
+func (w *Writer) Snapshot() []LogEntry {
+	w.mutex.Lock()
+	entries := copyEntries(w.buffer)
+	w.mutex.Unlock()
+
+	for _, partition := range w.partitionList() {
+		entries = append(entries, partition.Snapshot()...)
+	}
+	return entries
+}
 
-	entries := make([]LogEntry, len(w.buffer))
-	copy(entries, w.buffer)
+// DrainBuffer removes and returns the buffered entries, including those
+// of every partition, without sending them to Datadog, e.g. so a crash
+// handler can write them to a file. The returned entries are not retried
+// or counted as sent.
+func (w *Writer) DrainBuffer() []LogEntry {
+	w.mutex.Lock()
+	entries := copyEntries(w.buffer)
+	w.wal.release(w.buffer)
+	clear(w.buffer)
 	w.buffer = w.buffer[:0]
+	w.resetBufferBytes()
+	if w.ageTimer != nil {
+		w.ageTimer.Stop()
+		w.ageTimer = nil
+	}
+	w.mutex.Unlock()
+
+	for _, partition := range w.partitionList() {
+		entries = append(entries, partition.DrainBuffer()...)
+	}
+	return entries
+}
+
+// copyEntries copies entries and their Fields maps, so callers cannot
+// observe later changes such as coalesced repeat counts
+func copyEntries(entries []LogEntry) []LogEntry {
+	out := make([]LogEntry, len(entries))
+	for i, entry := range entries {
+		if entry.Fields != nil {
+			fields := make(map[string]any, len(entry.Fields))
+			for key, value := range entry.Fields {
+				fields[key] = value
+			}
+			entry.Fields = fields
+		}
+		out[i] = entry
+	}
+	return out
+}
+
+// takeBuffer hands the buffered entries to a flush and leaves an empty
+// buffer accepting writes. By default the entries are copied; with
+// Config.DoubleBuffer the buffer itself is handed over and replaced by the
+// spare one, avoiding a per-flush allocation. Must be called with mutex
+// held.
+func (w *Writer) takeBuffer() []LogEntry {
+	w.resetBufferBytes()
+	if !w.config.DoubleBuffer {
+		entries := make([]LogEntry, len(w.buffer))
+		copy(entries, w.buffer)
+		w.buffer = w.recycleBuffer(w.buffer)
+		return entries
+	}
+
+	entries := w.buffer
+	w.buffer = w.spare
+	w.spare = nil
+	if w.buffer == nil {
+		w.buffer = w.newBuffer()
+	}
+	return entries
+}
+
+// newBuffer allocates an empty buffer of Config.InitialBufferCapacity
+func (w *Writer) newBuffer() []LogEntry {
+	return make([]LogEntry, 0, max(w.config.InitialBufferCapacity, w.config.BatchSize))
+}
+
+// recycleBuffer returns buffer emptied for reuse, or a fresh buffer when
+// a burst grew it past four times Config.InitialBufferCapacity, so the
+// burst's memory is not held for the writer's lifetime
+func (w *Writer) recycleBuffer(buffer []LogEntry) []LogEntry {
+	if cap(buffer) > 4*max(w.config.InitialBufferCapacity, w.config.BatchSize) {
+		return w.newBuffer()
+	}
+	return buffer[:0]
+}
+
+// releaseBuffer keeps a delivered batch's backing array as the spare
+// buffer for the next flush
+func (w *Writer) releaseBuffer(entries []LogEntry) {
+	clear(entries)
+
+	w.mutex.Lock()
+	if w.spare == nil {
+		w.spare = w.recycleBuffer(entries)
+	}
 	w.mutex.Unlock()
+}
+
+// sortByTime orders entries by timestamp. The sort is stable, so the
+// buffer order breaks ties.
+func sortByTime(entries []LogEntry) {
+	slices.SortStableFunc(entries, func(a, b LogEntry) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+}
+
+// deliver ships a flushed batch. Entries with oversized messages are
+// isolated in their own requests, so a single pathological entry cannot
+// push the whole batch over the intake limits.
+func (w *Writer) deliver(entries []LogEntry) error {
+	if w.config.SortBatchByTime {
+		sortByTime(entries)
+	}
+	token := w.trackInFlight(entries)
+	defer w.releaseInFlight(token)
+
+	id := w.correlationID()
+	w.stampCorrelation(entries, id)
+	regular, large := w.partitionLarge(entries)
 
-	return w.sendToDatadog(entries)
+	var errs []error
+	if len(regular) > 0 {
+		errs = append(errs, w.sendBatches(splitBatch(regular), id))
+	}
+	for _, entry := range large {
+		errs = append(errs, w.sendBatch([]LogEntry{w.truncateEntry(entry)}, id))
+	}
+	return errors.Join(errs...)
 }
 
-func (w *Writer) sendToDatadog(entries []LogEntry) error {
-	payload, err := json.Marshal(entries)
+// sendToDatadog sends one request's worth of entries, retrying as
+// configured. A batch that could not be delivered is reported through
+// failBatch unless report is false, when the caller takes over the batch
+// and reports it once it gives up (see retryAfterClose).
+func (w *Writer) sendToDatadog(entries []LogEntry, correlationID string, report bool) error {
+	inFlight := w.inFlight.Add(1)
+	defer w.inFlight.Add(-1)
+
+	payload, contentType, err := w.serialize(entries)
 	if err != nil {
-		w.handleError(fmt.Errorf("failed to marshal log entries: %w", err))
+		err = permanentError{fmt.Errorf("%w: %w", errMarshal, err)}
+		if report {
+			w.failBatch(entries, err)
+		}
 		return err
 	}
 
 	// Apply compression if enabled
 	var body []byte
 	var contentEncoding string
-	if w.config.EnableCompression {
-		var buf bytes.Buffer
-		gz := gzip.NewWriter(&buf)
-		if _, err := gz.Write(payload); err != nil {
-			w.handleError(fmt.Errorf("failed to compress payload: %w", err))
-			return err
-		}
-		if err := gz.Close(); err != nil {
-			w.handleError(fmt.Errorf("failed to close gzip writer: %w", err))
-			return err
+	compress := w.config.EnableCompression
+	if compress && w.config.CompressionMaxInFlight > 0 && inFlight > int64(w.config.CompressionMaxInFlight) {
+		compress = false
+		w.stats.compressionSkipped.Add(1)
+	}
+	if compress {
+		body, err = w.compressPayload(payload)
+		if err != nil {
+			// The payload is still deliverable, only larger
+			w.handleError(fmt.Errorf("sending batch uncompressed: %w", err))
+			w.stats.compressionFallbacks.Add(1)
+			compress = false
 		}
-		body = buf.Bytes()
+	}
+	if compress {
 		contentEncoding = "gzip"
+		if w.config.OnCompress != nil {
+			w.config.OnCompress(len(payload), len(body))
+		}
 	} else {
 		body = payload
 	}
 
-	// Build the Datadog intake URL
-	var url string
-	if strings.Contains(w.config.Site, "127.0.0.1") || strings.Contains(w.config.Site, "localhost") {
-		// For local testing/development
-		url = fmt.Sprintf("http://%s/v1/input/%s", w.config.Site, w.config.APIKey)
-	} else {
-		// Standard Datadog endpoint
-		url = fmt.Sprintf("https://http-intake.logs.%s/v1/input/%s", w.config.Site, w.config.APIKey)
+	request := intakeRequest{
+		url:             w.intakeURL(),
+		body:            body,
+		contentType:     contentType,
+		contentEncoding: contentEncoding,
+		correlationID:   correlationID,
+		timeout:         w.requestTimeout(len(entries)),
+	}
+	if w.config.CaptureMode {
+		w.capture(entries, request)
+		w.recordSuccess(len(entries))
+		w.wal.release(entries)
+		return nil
 	}
 
+	// Sends started by Close's final flush keep their retries; sends
+	// already in flight when Close is called abort their backoff
+	finalFlush := w.closed.Load()
+
 	var lastErr error
-	for attempt := 0; attempt <= w.config.MaxRetries; attempt++ {
+	permanent := false
+	baseDelay, maxRetries := w.retryPolicy(finalFlush)
+	retryDelay := baseDelay
+	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			time.Sleep(w.config.RetryDelay * time.Duration(attempt))
+			if w.budget != nil && !w.budget.allowRetry() {
+				w.stats.retriesDenied.Add(1)
+				break
+			}
+			if !w.backoff(retryDelay*time.Duration(attempt), finalFlush) {
+				lastErr = fmt.Errorf("retry aborted by Close: %w", lastErr)
+				break
+			}
+		} else if w.budget != nil {
+			w.budget.onRequest()
 		}
 
-		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
-		if err != nil {
-			lastErr = fmt.Errorf("failed to create request: %w", err)
-			continue
+		if w.inCooldown() {
+			if w.requeue(entries) {
+				return nil
+			}
+			if !w.awaitCooldown() {
+				lastErr = ErrCooldown
+				break
+			}
 		}
-
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("DD-API-KEY", w.config.APIKey)
-		if contentEncoding != "" {
-			req.Header.Set("Content-Encoding", contentEncoding)
+		if w.dnsCircuitOpen() {
+			lastErr = w.dnsError(nil)
+			w.stats.dnsFastFails.Add(1)
+			break
 		}
 
-		resp, err := w.client.Do(req)
+		resp, errorBody, err := w.doRequest(request)
+		if errors.Is(err, errLifetimeExhausted) {
+			if w.requeue(entries) {
+				return nil
+			}
+			// Closed meanwhile: Close's sends are not capped
+			resp, errorBody, err = w.doRequest(request)
+		}
+		if w.observeDNS(err) {
+			lastErr = w.dnsError(err)
+			break
+		}
 		if err != nil {
-			lastErr = fmt.Errorf("failed to send request: %w", err)
+			lastErr = err
+			if errors.Is(err, errSigning) {
+				permanent = true
+				break
+			}
 			continue
 		}
 
-		_ = resp.Body.Close()
-
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			w.recordSuccess(len(entries))
+			w.wal.release(entries)
 			return nil
 		}
 
 		lastErr = fmt.Errorf("datadog API error: status %d", resp.StatusCode)
+		if errorBody != "" {
+			lastErr = fmt.Errorf("datadog API error: status %d: %s", resp.StatusCode, errorBody)
+		}
+
+		// Gateway congestion: back off longer than for other failures
+		retryDelay = baseDelay
+		if w.config.GatewayBackoff > 0 && w.isGatewayStatus(resp.StatusCode) && !(finalFlush && w.config.CloseRetryDelay != 0) {
+			retryDelay = w.config.GatewayBackoff
+		}
+
+		// Rate limited: pause all sends and keep the batch buffered
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				w.startCooldown(delay)
+				if w.requeue(entries) {
+					return nil
+				}
+				// Closed: the next attempt waits out the cooldown or gives up
+				continue
+			}
+		}
 
 		// Don't retry on client errors (4xx)
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			permanent = resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests
 			break
 		}
 	}
 
-	w.handleError(lastErr)
+	if permanent {
+		lastErr = permanentError{lastErr}
+	}
+	if report {
+		w.failBatch(entries, lastErr)
+	}
 	return lastErr
 }
 
+// failBatch reports a batch that will not be delivered. Serialization
+// failures are only counted as dropped; delivery failures also count
+// towards DisableAfterConsecutiveFailures.
+func (w *Writer) failBatch(entries []LogEntry, err error) {
+	w.handleError(err)
+	if errors.Is(err, errMarshal) {
+		w.stats.dropped.Add(uint64(len(entries)))
+		return
+	}
+	w.recordFailure(len(entries))
+}
+
+// newTransport builds the HTTP transport used when no Config.HTTPClient is
+// given. Idle connection limits favour sustained posting to a single host.
+func newTransport(config Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = config.MaxIdleConns
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = config.IdleConnTimeout
+	return transport
+}
+
+// backoff waits d before a retry. Unless the send belongs to the final
+// flush, the wait ends early when Close is called, and backoff reports
+// false so the retry is abandoned instead of delaying shutdown.
+func (w *Writer) backoff(d time.Duration, finalFlush bool) bool {
+	if finalFlush {
+		time.Sleep(d)
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// errSigning marks Config.SignRequest failures, which are not retried
+var errSigning = errors.New("request signing failed")
+
+// errMarshal marks batches that could not be serialized
+var errMarshal = errors.New("failed to marshal log entries")
+
+// permanentError wraps a send failure another attempt cannot fix: a
+// client error status other than 408 and 429, or a signing or
+// serialization failure. BackgroundCloseRetry does not retry these.
+type permanentError struct{ error }
+
+// Unwrap returns the underlying failure
+func (e permanentError) Unwrap() error { return e.error }
+
+// isPermanent reports whether err is a permanentError
+func isPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// errLifetimeExhausted is returned by doRequest once
+// Config.MaxLifetimeRequests has been used up
+var errLifetimeExhausted = errors.New("MaxLifetimeRequests reached")
+
+// intakeRequest is a single intake request, sent once per attempt
+type intakeRequest struct {
+	url             string
+	body            []byte
+	contentType     string
+	contentEncoding string
+	correlationID   string
+	timeout         time.Duration // Deadline for one attempt
+}
+
+// requestTimeout returns the deadline for a batch of count entries. With
+// Config.AdaptiveTimeout it grows by TimeoutPerEntry for every entry, up
+// to MaxTimeout, so full batches on slow links get more time than tiny
+// ones.
+func (w *Writer) requestTimeout(count int) time.Duration {
+	if !w.config.AdaptiveTimeout {
+		return w.config.Timeout
+	}
+	timeout := w.config.Timeout + w.config.TimeoutPerEntry*time.Duration(count)
+	return min(timeout, w.config.MaxTimeout)
+}
+
+// isGatewayStatus reports whether status signals intake congestion that
+// Config.GatewayBackoff applies to
+func (w *Writer) isGatewayStatus(status int) bool {
+	return status == http.StatusGatewayTimeout ||
+		(status == http.StatusServiceUnavailable && w.config.GatewayBackoffOn503)
+}
+
+// doRequest performs a single intake request. The response body is
+// drained and closed before returning, so only the status, headers and,
+// for failed requests, a bounded summary of the body are available to
+// the caller.
+func (w *Writer) doRequest(request intakeRequest) (*http.Response, string, error) {
+	ctx, cancel := context.WithTimeout(httptrace.WithClientTrace(context.Background(), w.trace), request.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, w.config.HTTPMethod, request.url, bytes.NewReader(request.body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	w.setIntakeHeaders(req.Header, request)
+	if w.config.SignRequest != nil {
+		if err := w.config.SignRequest(req, request.body); err != nil {
+			return nil, "", fmt.Errorf("%w: %w", errSigning, err)
+		}
+	}
+
+	if err := w.acquireSlot(); err != nil {
+		return nil, "", err
+	}
+	defer w.releaseSlot()
+
+	if !w.reserveRequest() {
+		return nil, "", errLifetimeExhausted
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.stats.failedRequests.Add(1)
+		return nil, "", fmt.Errorf("failed to send request: %w", err)
+	}
+
+	failed := resp.StatusCode < 200 || resp.StatusCode >= 300
+	var errorBody string
+	if failed {
+		errorBody = readErrorBody(resp.Body, w.config.MaxErrorBodyBytes)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	w.recordResponse(resp, errorBody, request.timeout)
+	if w.config.RespectRateLimitHeaders {
+		w.observeRateLimit(resp.Header)
+	}
+
+	if failed {
+		w.stats.failedRequests.Add(1)
+	}
+	return resp, errorBody, nil
+}
+
+// warmup primes the connection pool with a HEAD request to the intake.
+// Any response means the connection was established, so only transport
+// errors are reported.
+func (w *Writer) warmup() {
+	req, err := http.NewRequest("HEAD", w.intakeBaseURL()+"/", nil)
+	if err != nil {
+		w.handleError(fmt.Errorf("warmup failed: %w", err))
+		return
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.handleError(fmt.Errorf("warmup failed: %w", err))
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// setRequestHeaders sets the headers sent with every intake request.
+func (w *Writer) setRequestHeaders(header http.Header, contentType, contentEncoding string) {
+	header.Set("Content-Type", contentType)
+	header.Set("DD-API-KEY", w.config.APIKey)
+	if contentEncoding != "" {
+		header.Set("Content-Encoding", contentEncoding)
+	}
+}
+
+// setIntakeHeaders sets the headers of an intake request, including
+// Config.CorrelationHeader when the request carries a correlation ID
+func (w *Writer) setIntakeHeaders(header http.Header, request intakeRequest) {
+	w.setRequestHeaders(header, request.contentType, request.contentEncoding)
+	if request.correlationID != "" && w.config.CorrelationHeader != "" {
+		header.Set(w.config.CorrelationHeader, request.correlationID)
+	}
+}
+
+// intakeURL builds the Datadog logs intake URL for the configured site.
+func (w *Writer) intakeURL() string {
+	return fmt.Sprintf("%s/v1/input/%s", w.intakeBaseURL(), w.config.APIKey)
+}
+
+// intakeBaseURL returns the scheme and host of the logs intake.
+func (w *Writer) intakeBaseURL() string {
+	if isLocalSite(w.config.Site) {
+		// For local testing/development
+		return "http://" + w.config.Site
+	}
+	// Standard Datadog endpoint
+	return "https://http-intake.logs." + w.config.Site
+}
+
+// apiURL builds a Datadog API URL (e.g. /api/v1/validate) for the configured site.
+func (w *Writer) apiURL(path string) string {
+	if isLocalSite(w.config.Site) {
+		return fmt.Sprintf("http://%s%s", w.config.Site, path)
+	}
+	return fmt.Sprintf("https://api.%s%s", w.config.Site, path)
+}
+
+// resolveIntakeHost looks up the intake hostname so a mistyped Site fails
+// New instead of the first flush. Local and IP sites are not looked up.
+func (w *Writer) resolveIntakeHost() error {
+	site := w.config.Site
+	if h, _, err := net.SplitHostPort(site); err == nil {
+		site = h
+	}
+	if isLocalSite(site) || net.ParseIP(site) != nil {
+		return nil
+	}
+
+	host := strings.TrimPrefix(w.intakeBaseURL(), "https://")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if _, err := net.LookupHost(host); err != nil {
+		return fmt.Errorf("cannot resolve intake host %q, check Config.Site: %w", host, err)
+	}
+	return nil
+}
+
+// isLocalSite reports whether the site points at a local test server.
+func isLocalSite(site string) bool {
+	return strings.Contains(site, "127.0.0.1") || strings.Contains(site, "localhost")
+}
+
 func (w *Writer) startFlushTimer() {
 	w.timerMutex.Lock()
 	defer w.timerMutex.Unlock()
 
-	if w.closed {
+	if w.closed.Load() {
 		return
 	}
 
-	w.timer = time.AfterFunc(w.config.FlushInterval, func() {
-		_ = w.flush()
-		w.startFlushTimer()
-	})
+	w.guard.timer.Store(armFlushTimer(weak.Make(w), w.nextFlushDelay(time.Now())))
+}
+
+// nextFlushDelay returns how long to wait before the next timed flush.
+// With AlignFlushToWallClock the timer fires on the next multiple of
+// FlushInterval (e.g. every second on the second), so flushes line up
+// across a fleet; otherwise it fires FlushInterval from now.
+func (w *Writer) nextFlushDelay(now time.Time) time.Duration {
+	interval := w.config.FlushInterval
+	if !w.config.AlignFlushToWallClock {
+		return interval
+	}
+
+	delay := now.Truncate(interval).Add(interval).Sub(now)
+	if delay <= 0 {
+		return interval
+	}
+	return delay
 }
 
 func (w *Writer) handleError(err error) {
@@ -319,23 +2321,40 @@ func (w *Writer) handleError(err error) {
 	}
 }
 
-func mapLevel(level iris.Level) string {
+// mapLevel translates an iris level to a Datadog status. The second
+// result is false when the level is not known to the writer.
+func mapLevel(level iris.Level) (string, bool) {
 	switch level {
 	case iris.Debug:
-		return "debug"
+		return "debug", true
 	case iris.Info:
-		return "info"
+		return "info", true
 	case iris.Warn:
-		return "warn"
+		return "warn", true
 	case iris.Error:
-		return "error"
+		return "error", true
 	case iris.DPanic:
-		return "critical"
+		return "critical", true
 	case iris.Panic:
-		return "emergency"
+		return "emergency", true
 	case iris.Fatal:
-		return "critical"
+		return "critical", true
 	default:
-		return "info"
+		return "", false
+	}
+}
+
+// levelStatus returns the Datadog status for a level, falling back to
+// Config.DefaultLevelStatus and notifying OnUnknownLevel for unmapped levels.
+func (w *Writer) levelStatus(level iris.Level) string {
+	if status, ok := mapLevel(level); ok {
+		return status
+	}
+	if w.config.OnUnknownLevel != nil {
+		w.config.OnUnknownLevel(level)
+	}
+	if w.config.DefaultLevelStatus != "" {
+		return w.config.DefaultLevelStatus
 	}
+	return "info"
 }
@@ -7,6 +7,17 @@
 package datadogwriter
 
 import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -243,7 +254,8 @@ func TestBuildTagsString(t *testing.T) {
 				"service": "api",
 				"version": "1.0.0",
 			},
-			// Note: map iteration order is not guaranteed, so we check for valid format
+			// Keys are emitted in sorted order
+			expected: "env:production,service:api,version:1.0.0",
 		},
 	}
 
@@ -257,19 +269,8 @@ func TestBuildTagsString(t *testing.T) {
 
 			result := writer.buildTagsString()
 
-			if len(tt.tags) == 0 {
-				if result != tt.expected {
-					t.Errorf("buildTagsString() = %v, want %v", result, tt.expected)
-				}
-			} else if len(tt.tags) == 1 {
-				if result != tt.expected {
-					t.Errorf("buildTagsString() = %v, want %v", result, tt.expected)
-				}
-			} else {
-				// For multiple tags, just check format
-				if result == "" {
-					t.Error("buildTagsString() returned empty string for non-empty tags")
-				}
+			if result != tt.expected {
+				t.Errorf("buildTagsString() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
@@ -305,3 +306,1830 @@ func TestWriter_Compression(t *testing.T) {
 	// Test passes if no panics occur during compression
 	t.Log("✅ Compression test completed without errors")
 }
+
+func TestWriter_UpdateTags(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Tags:          map[string]string{"env": "staging"},
+		BatchSize:     1 << 20, // Keep everything buffered
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() {
+		writer.mutex.Lock()
+		writer.buffer = writer.buffer[:0]
+		writer.mutex.Unlock()
+		_ = writer.Close()
+	}()
+
+	record := iris.NewRecord(iris.Info, "message")
+	if got := writer.buildLogEntry(record).Tags; got != "env:staging" {
+		t.Fatalf("Tags = %q, want %q", got, "env:staging")
+	}
+
+	tags := map[string]string{"env": "production", "team": "core"}
+	writer.UpdateTags(tags)
+	tags["env"] = "mutated" // Caller mutations must not leak into the writer
+
+	if got := writer.buildLogEntry(record).Tags; got != "env:production,team:core" {
+		t.Errorf("Tags = %q, want %q", got, "env:production,team:core")
+	}
+
+	// Hammer the hot path and UpdateTags concurrently; run with -race
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = writer.WriteRecord(record)
+			}
+		}()
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				writer.UpdateTags(map[string]string{"writer": fmt.Sprint(g), "seq": fmt.Sprint(i)})
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestResolveHostname(t *testing.T) {
+	writer := &Writer{
+		config: Config{
+			Hostname:       "config-host",
+			HostnameFields: DefaultHostnameFields,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		fields   []iris.Field
+		expected string
+	}{
+		{
+			name:     "no host fields",
+			expected: "config-host",
+		},
+		{
+			name:     "host field",
+			fields:   []iris.Field{iris.Str("host", "web-01")},
+			expected: "web-01",
+		},
+		{
+			name: "chain priority",
+			fields: []iris.Field{
+				iris.Str("syslog.hostname", "syslog-host"),
+				iris.Str("hostname", "record-hostname"),
+			},
+			expected: "record-hostname",
+		},
+		{
+			name:     "empty and non-string values are skipped",
+			fields:   []iris.Field{iris.Str("host", ""), iris.Int("hostname", 42)},
+			expected: "config-host",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := iris.NewRecord(iris.Info, "message")
+			for _, field := range tt.fields {
+				record.AddField(field)
+			}
+
+			if got := writer.buildLogEntry(record).Hostname; got != tt.expected {
+				t.Errorf("Hostname = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildLogEntry_StructuredOnly(t *testing.T) {
+	t.Run("omit empty message", func(t *testing.T) {
+		writer := &Writer{config: Config{}}
+
+		record := iris.NewRecord(iris.Info, "")
+		record.AddField(iris.Str("event", "cache_miss"))
+
+		payload, err := json.Marshal(writer.buildLogEntry(record))
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if strings.Contains(string(payload), `"message"`) {
+			t.Errorf("Expected message to be omitted, got %s", payload)
+		}
+	})
+
+	t.Run("synthesize message from field", func(t *testing.T) {
+		writer := &Writer{config: Config{MessageFromField: "event"}}
+
+		record := iris.NewRecord(iris.Info, "")
+		record.AddField(iris.Str("event", "cache_miss"))
+
+		if got := writer.buildLogEntry(record).Message; got != "cache_miss" {
+			t.Errorf("Message = %q, want %q", got, "cache_miss")
+		}
+
+		record = iris.NewRecord(iris.Info, "explicit")
+		record.AddField(iris.Str("event", "cache_miss"))
+
+		if got := writer.buildLogEntry(record).Message; got != "explicit" {
+			t.Errorf("Message = %q, want record message to win", got)
+		}
+	})
+}
+
+func TestWriter_CompressionMaxInFlight(t *testing.T) {
+	encodings := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings <- r.Header.Get("Content-Encoding")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:                 "test-api-key",
+		Site:                   strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:              1,
+		FlushInterval:          time.Hour,
+		EnableCompression:      true,
+		CompressionMaxInFlight: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	record := iris.NewRecord(iris.Info, "message")
+
+	_ = writer.WriteRecord(record)
+	if got := <-encodings; got != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip below the threshold", got)
+	}
+
+	// Simulate other sends in flight
+	writer.inFlight.Store(2)
+	_ = writer.WriteRecord(record)
+	writer.inFlight.Store(0)
+	if got := <-encodings; got != "" {
+		t.Errorf("Content-Encoding = %q, want uncompressed above the threshold", got)
+	}
+
+	if got := writer.Stats().CompressionSkipped; got != 1 {
+		t.Errorf("CompressionSkipped = %d, want 1", got)
+	}
+}
+
+func TestWriter_MaxBufferAge(t *testing.T) {
+	received := make(chan time.Time, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- time.Now()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	t.Run("single idle entry ships within MaxBufferAge", func(t *testing.T) {
+		writer, err := New(Config{
+			APIKey:        "test-api-key",
+			Site:          strings.TrimPrefix(server.URL, "http://"),
+			BatchSize:     1000,
+			FlushInterval: time.Hour,
+			MaxBufferAge:  50 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		defer func() { _ = writer.Close() }()
+
+		written := time.Now()
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, "lonely entry"))
+
+		select {
+		case at := <-received:
+			if age := at.Sub(written); age < 50*time.Millisecond {
+				t.Errorf("Entry shipped after %v, before MaxBufferAge", age)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Entry was not shipped within MaxBufferAge")
+		}
+	})
+
+	t.Run("fully idle writer sends nothing", func(t *testing.T) {
+		writer, err := New(Config{
+			APIKey:        "test-api-key",
+			Site:          strings.TrimPrefix(server.URL, "http://"),
+			FlushInterval: 10 * time.Millisecond,
+			MaxBufferAge:  10 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		_ = writer.Close()
+
+		select {
+		case <-received:
+			t.Error("Idle writer issued a request")
+		default:
+		}
+	})
+}
+
+func TestWriter_UnknownLevel(t *testing.T) {
+	var unknown []iris.Level
+	writer := &Writer{
+		config: Config{
+			DefaultLevelStatus: "notice",
+			OnUnknownLevel: func(level iris.Level) {
+				unknown = append(unknown, level)
+			},
+		},
+	}
+
+	if got := writer.buildLogEntry(iris.NewRecord(iris.Warn, "known")).Level; got != "warn" {
+		t.Errorf("status = %q, want %q", got, "warn")
+	}
+
+	synthetic := iris.Level(42)
+	if got := writer.buildLogEntry(iris.NewRecord(synthetic, "unknown")).Level; got != "notice" {
+		t.Errorf("status = %q, want %q", got, "notice")
+	}
+	if len(unknown) != 1 || unknown[0] != synthetic {
+		t.Errorf("OnUnknownLevel calls = %v, want [%v]", unknown, synthetic)
+	}
+
+	// Without a configured default the historical "info" fallback applies
+	bare := &Writer{}
+	if got := bare.levelStatus(synthetic); got != "info" {
+		t.Errorf("status = %q, want %q", got, "info")
+	}
+}
+
+func TestLogEntry_MarshalJSON(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: 1757160000000,
+		Level:     "info",
+		Message:   "hello",
+		Service:   "api",
+		Fields: map[string]any{
+			"user_id": 12345,
+			"service": "shadowed",
+		},
+	}
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	want := `{"timestamp":1757160000000,"status":"info","message":"hello","service":"api","user_id":12345}`
+	if string(payload) != want {
+		t.Errorf("payload = %s, want %s", payload, want)
+	}
+}
+
+func TestLogEntry_MarshalJSONFlattensFields(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: 1757160000000,
+		Level:     "error",
+		Message:   "payment failed",
+		Service:   "checkout",
+		Source:    "go",
+		Tags:      "env:prod",
+		Hostname:  "web-1",
+		Fields: map[string]any{
+			"order_id": "A-1001",
+			"amount":   42.5,
+			"status":   "shadowed",
+		},
+	}
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("invalid payload %s: %v", payload, err)
+	}
+	want := map[string]any{
+		"timestamp": float64(1757160000000),
+		"status":    "error",
+		"message":   "payment failed",
+		"service":   "checkout",
+		"ddsource":  "go",
+		"ddtags":    "env:prod",
+		"hostname":  "web-1",
+		"order_id":  "A-1001",
+		"amount":    42.5,
+	}
+	if len(got) != len(want) {
+		t.Errorf("payload has %d keys, want %d: %s", len(got), len(want), payload)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %#v, want %#v", key, got[key], value)
+		}
+	}
+	for _, key := range []string{"", "Fields", "fields"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("payload nests attributes under %q: %s", key, payload)
+		}
+	}
+}
+
+func TestWriter_ServiceAttributeNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  map[string]any
+	}{
+		{
+			name:  "default reserved attribute",
+			names: []string{"service"},
+			want:  map[string]any{"service": "checkout"},
+		},
+		{
+			name:  "otel only",
+			names: []string{"service.name"},
+			want:  map[string]any{"service.name": "checkout"},
+		},
+		{
+			name:  "both",
+			names: []string{"service", "service.name"},
+			want:  map[string]any{"service": "checkout", "service.name": "checkout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &Writer{config: Config{Service: "checkout", ServiceAttributeNames: tt.names}}
+
+			payload, err := json.Marshal(writer.buildLogEntry(iris.NewRecord(iris.Info, "message")))
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			var decoded map[string]any
+			if err := json.Unmarshal(payload, &decoded); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			for _, key := range []string{"service", "service.name"} {
+				if decoded[key] != tt.want[key] {
+					t.Errorf("%s = %v, want %v", key, decoded[key], tt.want[key])
+				}
+			}
+		})
+	}
+}
+
+func TestNew_Warmup(t *testing.T) {
+	methods := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods <- r.Method
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var warmupErr error
+	writer, err := New(Config{
+		APIKey:  "test-api-key",
+		Site:    strings.TrimPrefix(server.URL, "http://"),
+		Warmup:  true,
+		OnError: func(err error) { warmupErr = err },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	select {
+	case method := <-methods:
+		if method != "HEAD" {
+			t.Errorf("Warmup method = %s, want HEAD", method)
+		}
+	default:
+		t.Fatal("Expected a warmup request before New() returned")
+	}
+	if warmupErr != nil {
+		t.Errorf("Unexpected warmup error: %v", warmupErr)
+	}
+}
+
+func TestWriter_SourceField(t *testing.T) {
+	writer := &Writer{config: Config{Source: "go", SourceField: "dd.source"}}
+
+	record := iris.NewRecord(iris.Info, "query executed")
+	record.AddField(iris.Str("dd.source", "postgresql"))
+	if got := writer.buildLogEntry(record).Source; got != "postgresql" {
+		t.Errorf("Source = %q, want %q", got, "postgresql")
+	}
+
+	record = iris.NewRecord(iris.Info, "plain")
+	if got := writer.buildLogEntry(record).Source; got != "go" {
+		t.Errorf("Source = %q, want fallback %q", got, "go")
+	}
+}
+
+func TestWriter_CoalesceDuplicates(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:             "test-api-key",
+		BatchSize:          100,
+		FlushInterval:      time.Hour,
+		CoalesceDuplicates: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() {
+		writer.mutex.Lock()
+		writer.buffer = writer.buffer[:0]
+		writer.mutex.Unlock()
+		_ = writer.Close()
+	}()
+
+	for i := 0; i < 3; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Warn, "connection reset"))
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "connection reset"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "connection reset"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Warn, "connection reset"))
+
+	writer.mutex.Lock()
+	buffered := append([]LogEntry(nil), writer.buffer...)
+	writer.mutex.Unlock()
+
+	if len(buffered) != 3 {
+		t.Fatalf("Expected 3 buffered entries, got %d", len(buffered))
+	}
+	wantCounts := []any{3, 2, nil}
+	for i, want := range wantCounts {
+		if got := buffered[i].Fields[repeatCountKey]; got != want {
+			t.Errorf("entry %d repeat count = %v, want %v", i, got, want)
+		}
+	}
+	if got := writer.Stats().EntriesCoalesced; got != 3 {
+		t.Errorf("EntriesCoalesced = %d, want 3", got)
+	}
+}
+
+func TestWriter_OmitAttributes(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:         "test-api-key",
+		Service:        "api",
+		Environment:    "production",
+		Version:        "1.0.0",
+		Hostname:       "web-01",
+		OmitAttributes: []string{"env", "version", "hostname"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Info, "message"))
+	if entry.Env != "" || entry.Version != "" || entry.Hostname != "" {
+		t.Errorf("Expected env, version and hostname to be omitted, got %+v", entry)
+	}
+	if entry.Service != "api" || entry.Source != "go" {
+		t.Errorf("Expected service and ddsource to be kept, got %+v", entry)
+	}
+}
+
+func TestNextFlushDelay(t *testing.T) {
+	now := time.Date(2025, 9, 6, 12, 0, 0, 300*int(time.Millisecond), time.UTC)
+
+	relative := &Writer{config: Config{FlushInterval: time.Second}}
+	if got := relative.nextFlushDelay(now); got != time.Second {
+		t.Errorf("relative delay = %v, want %v", got, time.Second)
+	}
+
+	aligned := &Writer{config: Config{FlushInterval: time.Second, AlignFlushToWallClock: true}}
+	if got := aligned.nextFlushDelay(now); got != 700*time.Millisecond {
+		t.Errorf("aligned delay = %v, want %v", got, 700*time.Millisecond)
+	}
+
+	onBoundary := time.Date(2025, 9, 6, 12, 0, 5, 0, time.UTC)
+	aligned.config.FlushInterval = 5 * time.Second
+	if got := aligned.nextFlushDelay(onBoundary); got != 5*time.Second {
+		t.Errorf("aligned delay on boundary = %v, want %v", got, 5*time.Second)
+	}
+}
+
+func BenchmarkBuildTagsString(b *testing.B) {
+	writer := &Writer{
+		config: Config{
+			Tags: map[string]string{
+				"env":       "production",
+				"service":   "api",
+				"version":   "1.0.0",
+				"team":      "backend",
+				"component": "ingest",
+			},
+		},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = writer.buildTagsString()
+	}
+}
+
+func BenchmarkBuildLogEntry_Tagged(b *testing.B) {
+	writer, err := New(Config{
+		APIKey: "test-api-key",
+		Tags: map[string]string{
+			"env":       "production",
+			"service":   "api",
+			"version":   "1.0.0",
+			"team":      "backend",
+			"component": "ingest",
+		},
+	})
+	if err != nil {
+		b.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	record := &iris.Record{Level: iris.Info, Msg: "benchmark message"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = writer.buildLogEntry(record)
+	}
+}
+
+// fakeWriter is a minimal DatadogWriter used to check the interface can be
+// satisfied outside the concrete implementation
+type fakeWriter struct {
+	records []*iris.Record
+	flushes int
+}
+
+func (f *fakeWriter) WriteRecord(record *iris.Record) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+func (f *fakeWriter) Flush() error { f.flushes++; return nil }
+func (f *fakeWriter) Close() error { return f.Flush() }
+func (f *fakeWriter) Stats() Stats { return Stats{EntriesSent: uint64(len(f.records))} }
+
+func TestDatadogWriterInterface(t *testing.T) {
+	fake := &fakeWriter{}
+	var writer DatadogWriter = fake
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "hello"))
+	_ = writer.Close()
+
+	if got := writer.Stats().EntriesSent; got != 1 {
+		t.Errorf("EntriesSent = %d, want 1", got)
+	}
+	if fake.flushes != 1 {
+		t.Errorf("flushes = %d, want 1", fake.flushes)
+	}
+}
+
+func TestFlushSendsBufferedEntries(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&logs)
+		received.Add(int64(len(logs)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "one"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "two"))
+
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := received.Load(); got != 2 {
+		t.Errorf("received = %d, want 2", got)
+	}
+}
+
+func TestWriter_GatewayBackoff(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:         "test-key",
+		Site:           strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:      1,
+		FlushInterval:  time.Hour,
+		RetryDelay:     time.Millisecond,
+		GatewayBackoff: 150 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	start := time.Now()
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "congested"))
+	elapsed := time.Since(start)
+
+	if calls.Load() != 2 {
+		t.Fatalf("calls = %d, want 504 followed by a successful retry", calls.Load())
+	}
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("retry after %v, want at least GatewayBackoff", elapsed)
+	}
+	if got := writer.Stats().EntriesSent; got != 1 {
+		t.Errorf("EntriesSent = %d, want 1", got)
+	}
+}
+
+func TestWriter_MaxLogAge(t *testing.T) {
+	writer := &Writer{config: Config{
+		MaxLogAge:    time.Hour,
+		Output:       OutputStdout,
+		OutputWriter: &strings.Builder{},
+	}}
+	writer.tags.Store(&tagSet{})
+
+	stale := iris.NewRecord(iris.Info, "replayed")
+	stale.AddField(iris.Time(timestampKey, time.Now().Add(-2*time.Hour)))
+	if err := writer.WriteRecord(stale); err != nil {
+		t.Fatalf("WriteRecord() error = %v", err)
+	}
+
+	fresh := iris.NewRecord(iris.Info, "recent")
+	when := time.Now().Add(-time.Minute)
+	fresh.AddField(iris.Time(timestampKey, when))
+	if err := writer.WriteRecord(fresh); err != nil {
+		t.Fatalf("WriteRecord() error = %v", err)
+	}
+
+	stats := writer.Stats()
+	if stats.EntriesExpired != 1 || stats.EntriesDropped != 1 {
+		t.Errorf("EntriesExpired/EntriesDropped = %d/%d, want 1/1", stats.EntriesExpired, stats.EntriesDropped)
+	}
+	if got := writer.buildLogEntry(fresh).Timestamp; got != when.UnixMilli() {
+		t.Errorf("Timestamp = %d, want record timestamp %d", got, when.UnixMilli())
+	}
+}
+
+func TestWriter_JoinContinuations(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:            "test-api-key",
+		BatchSize:         100,
+		FlushInterval:     time.Hour,
+		JoinContinuations: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() {
+		writer.mutex.Lock()
+		writer.buffer = writer.buffer[:0]
+		writer.mutex.Unlock()
+		_ = writer.Close()
+	}()
+
+	continuation := func(msg string) *iris.Record {
+		record := iris.NewRecord(iris.Error, msg)
+		record.AddField(iris.Bool(continuationKey, true))
+		return record
+	}
+
+	// A dangling continuation with nothing buffered stands on its own
+	_ = writer.WriteRecord(continuation("orphan"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "panic: boom"))
+	_ = writer.WriteRecord(continuation("goroutine 1 [running]:"))
+	_ = writer.WriteRecord(continuation("main.main()"))
+
+	writer.mutex.Lock()
+	buffered := append([]LogEntry(nil), writer.buffer...)
+	writer.mutex.Unlock()
+
+	if len(buffered) != 2 {
+		t.Fatalf("Expected 2 buffered entries, got %d", len(buffered))
+	}
+	if buffered[0].Message != "orphan" {
+		t.Errorf("first message = %q, want %q", buffered[0].Message, "orphan")
+	}
+	if want := "panic: boom\ngoroutine 1 [running]:\nmain.main()"; buffered[1].Message != want {
+		t.Errorf("joined message = %q, want %q", buffered[1].Message, want)
+	}
+	if got := writer.Stats().EntriesJoined; got != 2 {
+		t.Errorf("EntriesJoined = %d, want 2", got)
+	}
+}
+
+func TestWriter_DefaultFields(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		DefaultFields: map[string]any{"region": "eu-west-1", "build_id": 42},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Info, "message"))
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"region":"eu-west-1"`) || !strings.Contains(string(data), `"build_id":42`) {
+		t.Errorf("Expected default fields as attributes, got %s", data)
+	}
+
+	entry.Fields["region"] = "changed"
+	if writer.config.DefaultFields["region"] != "eu-west-1" {
+		t.Error("Entry fields must not alias Config.DefaultFields")
+	}
+}
+
+func TestWriter_DoubleBufferConcurrent(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&logs)
+		received.Add(int64(len(logs)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     16,
+		FlushInterval: time.Millisecond,
+		DoubleBuffer:  true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	const goroutines, perGoroutine = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				_ = writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("g%d-%d", g, i)))
+			}
+		}(g)
+	}
+	wg.Wait()
+	_ = writer.Close()
+
+	if got := received.Load(); got != goroutines*perGoroutine {
+		t.Errorf("received = %d, want %d", got, goroutines*perGoroutine)
+	}
+}
+
+func benchmarkTakeBuffer(b *testing.B, doubleBuffer bool) {
+	writer := &Writer{config: Config{BatchSize: 1000, DoubleBuffer: doubleBuffer}}
+	writer.buffer = make([]LogEntry, 0, 1000)
+	writer.spare = make([]LogEntry, 0, 1000)
+	entry := LogEntry{Level: "info", Message: "benchmark"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 1000; j++ {
+			writer.buffer = append(writer.buffer, entry)
+		}
+		entries := writer.takeBuffer()
+		if doubleBuffer {
+			writer.releaseBuffer(entries)
+		}
+	}
+}
+
+func BenchmarkTakeBuffer_Copy(b *testing.B)         { benchmarkTakeBuffer(b, false) }
+func BenchmarkTakeBuffer_DoubleBuffer(b *testing.B) { benchmarkTakeBuffer(b, true) }
+
+func TestNew_ResolveHostOnStart(t *testing.T) {
+	_, err := New(Config{
+		APIKey:             "test-api-key",
+		Site:               "datadoghq.invalid",
+		ResolveHostOnStart: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "http-intake.logs.datadoghq.invalid") {
+		t.Errorf("New() error = %v, want resolution error naming the intake host", err)
+	}
+
+	for _, site := range []string{"127.0.0.1:8080", "localhost", "10.1.2.3", "[::1]:9000"} {
+		writer, err := New(Config{APIKey: "test-api-key", Site: site, ResolveHostOnStart: true})
+		if err != nil {
+			t.Errorf("New(Site: %q) error = %v, want lookup skipped", site, err)
+			continue
+		}
+		_ = writer.Close()
+	}
+}
+
+func TestWriter_AdaptiveTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var observed []ResponseInfo
+	writer, err := New(Config{
+		APIKey:          "test-key",
+		Site:            strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:       3,
+		FlushInterval:   time.Hour,
+		Timeout:         100 * time.Millisecond,
+		AdaptiveTimeout: true,
+		TimeoutPerEntry: 50 * time.Millisecond,
+		OnResponse:      func(info ResponseInfo) { observed = append(observed, info) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for i := 0; i < 3; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, "slow link"))
+	}
+
+	if len(observed) != 1 || observed[0].Failed() {
+		t.Fatalf("responses = %+v, want one successful response", observed)
+	}
+	if observed[0].Timeout != 250*time.Millisecond {
+		t.Errorf("Timeout = %v, want 250ms", observed[0].Timeout)
+	}
+
+	if got := writer.requestTimeout(1000); got != writer.config.MaxTimeout {
+		t.Errorf("requestTimeout(1000) = %v, want MaxTimeout %v", got, writer.config.MaxTimeout)
+	}
+}
+
+func TestWriter_ConnectionReuse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for i := 0; i < 5; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, "reuse"))
+	}
+
+	stats := writer.Stats()
+	if stats.Requests != 5 || stats.NewConnections != 1 {
+		t.Errorf("Requests/NewConnections = %d/%d, want 5/1", stats.Requests, stats.NewConnections)
+	}
+}
+
+type countingTransport struct {
+	calls atomic.Int64
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.calls.Add(1)
+	return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody, Header: make(http.Header)}, nil
+}
+
+func TestWriter_HTTPClient(t *testing.T) {
+	transport := &countingTransport{}
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		HTTPClient:    &http.Client{Transport: transport},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "custom client"))
+
+	if got := transport.calls.Load(); got != 1 {
+		t.Errorf("custom transport calls = %d, want 1", got)
+	}
+}
+
+func TestWriter_OnCompress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var raw, compressed []int
+	writer, err := New(Config{
+		APIKey:                 "test-api-key",
+		Site:                   strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:              10,
+		FlushInterval:          time.Hour,
+		EnableCompression:      true,
+		CompressionMaxInFlight: 1,
+		OnCompress: func(rawBytes, compressedBytes int) {
+			raw = append(raw, rawBytes)
+			compressed = append(compressed, compressedBytes)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for i := 0; i < 10; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, "highly repetitive message"))
+	}
+	if len(raw) != 1 || compressed[0] >= raw[0] {
+		t.Fatalf("OnCompress sizes raw=%v compressed=%v, want one smaller compressed batch", raw, compressed)
+	}
+
+	// Compression skipped: the hook must not fire
+	writer.inFlight.Store(1)
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "uncompressed"))
+	_ = writer.Flush()
+	writer.inFlight.Store(0)
+	if len(raw) != 1 {
+		t.Errorf("OnCompress called %d times, want 1", len(raw))
+	}
+}
+
+func TestWriter_ConcurrentStartupAndClose(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logs []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&logs)
+		received.Add(int64(len(logs)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	for round := 0; round < 20; round++ {
+		received.Store(0)
+		writer, err := New(Config{
+			APIKey:        "test-key",
+			Site:          strings.TrimPrefix(server.URL, "http://"),
+			BatchSize:     4,
+			FlushInterval: time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		var accepted atomic.Int64
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 10; i++ {
+					if writer.WriteRecord(iris.NewRecord(iris.Info, "startup")) == nil {
+						accepted.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = writer.Close()
+		}()
+		wg.Wait()
+		_ = writer.Close()
+
+		if got, want := received.Load(), accepted.Load(); got != want {
+			t.Fatalf("round %d: received %d entries, %d writes were accepted", round, got, want)
+		}
+	}
+}
+
+func TestWriter_WriteAfterClose(t *testing.T) {
+	writer, err := New(Config{APIKey: "test-key", FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.Close()
+
+	if err := writer.WriteRecord(iris.NewRecord(iris.Info, "late")); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("WriteRecord() after Close error = %v, want ErrWriterClosed", err)
+	}
+	if got := writer.Stats().EntriesDropped; got != 1 {
+		t.Errorf("EntriesDropped = %d, want 1", got)
+	}
+}
+
+func TestLogEntry_MarshalJSONStable(t *testing.T) {
+	newEntry := func() LogEntry {
+		fields := make(map[string]any)
+		for i := 0; i < 50; i++ {
+			fields[fmt.Sprintf("field_%02d", 49-i)] = i
+		}
+		fields["nested"] = map[string]any{"z": 1, "a": 2}
+		fields["status"] = "shadowed"
+		return LogEntry{Timestamp: 1757160000000, Level: "warn", Message: "stable", Fields: fields}
+	}
+
+	first, err := json.Marshal(newEntry())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := json.Marshal(newEntry())
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("Marshal output differs between runs:\n%s\n%s", first, again)
+		}
+	}
+
+	prefix := `{"timestamp":1757160000000,"status":"warn","message":"stable","field_00":49,"field_01":48,`
+	if !strings.HasPrefix(string(first), prefix) {
+		t.Errorf("payload = %s, want prefix %s", first, prefix)
+	}
+	if !strings.HasSuffix(string(first), `"field_49":0,"nested":{"a":2,"z":1}}`) {
+		t.Errorf("payload = %s, want sorted fields", first)
+	}
+}
+
+func TestWriter_SourceFromLoggerName(t *testing.T) {
+	writer := &Writer{config: Config{Source: "go", SourceField: "dd.source", SourceFromLoggerName: true}}
+
+	record := iris.NewRecord(iris.Info, "charge created")
+	record.Logger = "Billing API"
+	if got := writer.buildLogEntry(record).Source; got != "billing_api" {
+		t.Errorf("Source = %q, want %q", got, "billing_api")
+	}
+
+	record.AddField(iris.Str("dd.source", "stripe"))
+	if got := writer.buildLogEntry(record).Source; got != "stripe" {
+		t.Errorf("Source = %q, SourceField must take precedence", got)
+	}
+
+	if got := writer.buildLogEntry(iris.NewRecord(iris.Info, "anonymous")).Source; got != "go" {
+		t.Errorf("Source = %q, want fallback %q", got, "go")
+	}
+}
+
+func TestWriter_SnapshotAndDrain(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:             "test-key",
+		Site:               strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:      time.Hour,
+		CoalesceDuplicates: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "first"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "second"))
+
+	snapshot := writer.Snapshot()
+	if len(snapshot) != 2 || snapshot[1].Message != "second" {
+		t.Fatalf("Snapshot() = %+v, want both buffered entries", snapshot)
+	}
+
+	// Later changes to the buffer must not leak into the snapshot
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "second"))
+	if _, ok := snapshot[1].Fields[repeatCountKey]; ok {
+		t.Error("Snapshot() shares Fields with the buffer")
+	}
+
+	drained := writer.DrainBuffer()
+	if len(drained) != 2 || drained[1].Fields[repeatCountKey] != 2 {
+		t.Fatalf("DrainBuffer() = %+v, want the coalesced buffer", drained)
+	}
+	if len(writer.Snapshot()) != 0 {
+		t.Error("DrainBuffer() must leave the buffer empty")
+	}
+
+	_ = writer.Flush()
+	if requests.Load() != 0 {
+		t.Errorf("requests = %d, Snapshot and DrainBuffer must not send", requests.Load())
+	}
+}
+
+func TestWriter_WriteRecordAt(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		FlushInterval: time.Hour,
+		MaxLogAge:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() {
+		_ = writer.DrainBuffer()
+		_ = writer.Close()
+	}()
+
+	at := time.Now().Add(-10 * time.Minute).Truncate(time.Millisecond)
+	record := iris.NewRecord(iris.Info, "replayed")
+	record.AddField(iris.Time(timestampKey, time.Now().Add(-time.Minute)))
+	if err := writer.WriteRecordAt(at, record); err != nil {
+		t.Fatalf("WriteRecordAt() error = %v", err)
+	}
+	if got := writer.Snapshot()[0].Timestamp; got != at.UnixMilli() {
+		t.Errorf("Timestamp = %d, want explicit %d", got, at.UnixMilli())
+	}
+
+	err = writer.WriteRecordAt(time.Now().Add(-2*time.Hour), iris.NewRecord(iris.Info, "ancient"))
+	if !errors.Is(err, ErrLogTooOld) {
+		t.Errorf("WriteRecordAt() error = %v, want ErrLogTooOld", err)
+	}
+	if got := writer.Stats().EntriesExpired; got != 1 {
+		t.Errorf("EntriesExpired = %d, want 1", got)
+	}
+}
+
+func TestWriter_IncludeUptime(t *testing.T) {
+	writer := &Writer{
+		config:    Config{IncludeUptime: true},
+		startedAt: time.Now().Add(-1500 * time.Millisecond).UnixNano(),
+	}
+
+	uptime, ok := writer.buildLogEntry(iris.NewRecord(iris.Info, "message")).Fields[uptimeKey].(int64)
+	if !ok || uptime < 1400 || uptime > 3000 {
+		t.Errorf("uptime_ms = %v, want about 1500", uptime)
+	}
+
+	writer.config.IncludeUptime = false
+	if _, ok := writer.buildLogEntry(iris.NewRecord(iris.Info, "message")).Fields[uptimeKey]; ok {
+		t.Error("uptime_ms must be absent by default")
+	}
+}
+
+func TestWriter_CloseInterruptsRetryBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	dropped := make(chan []LogEntry, 1)
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		MaxRetries:    3,
+		RetryDelay:    5 * time.Second,
+		OnDropBatch:   func(entries []LogEntry, err error) { dropped <- entries },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	written := make(chan error, 1)
+	go func() { written <- writer.WriteRecord(iris.NewRecord(iris.Error, "in backoff")) }()
+
+	// Wait for the first attempt to fail and the backoff to start
+	deadline := time.Now().Add(2 * time.Second)
+	for writer.Stats().FailedRequests == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := writer.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	select {
+	case err := <-written:
+		if err == nil || !strings.Contains(err.Error(), "aborted by Close") {
+			t.Errorf("WriteRecord() error = %v, want aborted retry", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Retry backoff was not interrupted by Close")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %v", elapsed)
+	}
+
+	select {
+	case entries := <-dropped:
+		if len(entries) != 1 || entries[0].Message != "in backoff" {
+			t.Errorf("OnDropBatch entries = %+v", entries)
+		}
+	default:
+		t.Error("OnDropBatch was not called for the aborted batch")
+	}
+}
+
+func TestNew_SourceDefault(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"default", Config{}, "go"},
+		{"explicit", Config{Source: "json"}, "json"},
+		{"no source", Config{NoSource: true}, ""},
+		{"no source wins", Config{Source: "json", NoSource: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			tt.config.Output = OutputStdout
+			tt.config.OutputWriter = &out
+			writer, err := New(tt.config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			_ = writer.WriteRecord(iris.NewRecord(iris.Info, "hello"))
+			_ = writer.Close()
+
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(out.String()), &entry); err != nil {
+				t.Fatalf("invalid output %q: %v", out.String(), err)
+			}
+			got, present := entry["ddsource"]
+			if tt.want == "" && present {
+				t.Errorf("ddsource = %v, want it omitted", got)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("ddsource = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_MinFlushInterval(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:      true,
+		BatchSize:        1,
+		FlushInterval:    time.Hour,
+		MinFlushInterval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for i := 0; i < 10; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("burst %d", i)))
+	}
+	if got := len(writer.CapturedBatches()); got != 1 {
+		t.Fatalf("batches sent during burst = %d, want 1", got)
+	}
+	if got := writer.Stats().FlushesCoalesced; got != 9 {
+		t.Errorf("FlushesCoalesced = %d, want 9", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(writer.CapturedBatches()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	batches := writer.CapturedBatches()
+	if len(batches) != 2 || len(batches[1].Entries) != 9 {
+		t.Errorf("batches = %d, want the deferred flush to send the other 9 entries together", len(batches))
+	}
+}
+
+func TestWriter_MinFlushIntervalAfterEmptyDeferredFlush(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:      true,
+		BatchSize:        1,
+		FlushInterval:    time.Hour,
+		MinFlushInterval: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	// The deferred flush fires on a buffer DrainBuffer already emptied
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "sent"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "deferred"))
+	_ = writer.DrainBuffer()
+	time.Sleep(100 * time.Millisecond)
+
+	writer.mutex.Lock()
+	stale := writer.deferTimer != nil
+	writer.mutex.Unlock()
+	if stale {
+		t.Error("deferTimer still set after the deferred flush fired")
+	}
+}
+
+func TestWriter_IncludeOriginalLevel(t *testing.T) {
+	writer := &Writer{config: Config{IncludeOriginalLevel: true}}
+
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Warn, "message"))
+	if entry.Fields[originalLevelKey] != iris.Warn.String() || entry.Level != "warn" {
+		t.Errorf("logger.level = %v, status = %q, want %q and warn", entry.Fields[originalLevelKey], entry.Level, iris.Warn.String())
+	}
+
+	writer.config.IncludeOriginalLevel = false
+	if _, ok := writer.buildLogEntry(iris.NewRecord(iris.Warn, "message")).Fields[originalLevelKey]; ok {
+		t.Error("logger.level must be absent by default")
+	}
+}
+
+func TestWriter_SignRequest(t *testing.T) {
+	var signatures, bodies []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		signatures = append(signatures, r.Header.Get("X-Signature"))
+		bodies = append(bodies, fmt.Sprintf("%x", sha256.Sum256(body)))
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:            "test-key",
+		Site:              strings.TrimPrefix(server.URL, "http://"),
+		EnableCompression: true,
+		FlushInterval:     time.Hour,
+		SignRequest: func(req *http.Request, body []byte) error {
+			req.Header.Set("X-Signature", fmt.Sprintf("%x", sha256.Sum256(body)))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "signed"))
+	_ = writer.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(signatures) != 1 || signatures[0] != bodies[0] {
+		t.Errorf("signatures = %v, body hashes = %v, want the signature over the sent bytes", signatures, bodies)
+	}
+}
+
+func TestWriter_SignRequestErrorAborts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var reported error
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval: time.Hour,
+		MaxRetries:    3,
+		RetryDelay:    time.Millisecond,
+		OnError:       func(err error) { reported = err },
+		SignRequest: func(*http.Request, []byte) error {
+			return errors.New("key unavailable")
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "unsigned"))
+	if err := writer.Close(); err == nil {
+		t.Error("Close() error = nil, want the signing failure")
+	}
+
+	if requests.Load() != 0 {
+		t.Errorf("requests = %d, want none", requests.Load())
+	}
+	if reported == nil || !strings.Contains(reported.Error(), "key unavailable") {
+		t.Errorf("OnError got %v, want the signing error", reported)
+	}
+	if stats := writer.Stats(); stats.Requests != 0 || stats.EntriesDropped != 1 {
+		t.Errorf("Requests = %d, EntriesDropped = %d, want 0 and 1", stats.Requests, stats.EntriesDropped)
+	}
+}
+
+func TestNew_ClampsFlushInterval(t *testing.T) {
+	var warning error
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		FlushInterval: time.Microsecond,
+		OnError:       func(err error) { warning = err },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if got := writer.EffectiveConfig().FlushInterval; got != minFlushInterval {
+		t.Errorf("FlushInterval = %v, want %v", got, minFlushInterval)
+	}
+	if warning == nil || !strings.Contains(warning.Error(), "FlushInterval") {
+		t.Errorf("OnError got %v, want a clamping warning", warning)
+	}
+}
+
+func TestWriter_Transforms(t *testing.T) {
+	var order []string
+	var out strings.Builder
+	writer, err := New(Config{
+		Output:       OutputStdout,
+		OutputWriter: &out,
+		Transforms: []func(*LogEntry){
+			func(e *LogEntry) {
+				order = append(order, "rename")
+				if value, ok := e.Fields["usr"]; ok {
+					e.Fields["user"] = value
+					delete(e.Fields, "usr")
+				}
+			},
+			func(e *LogEntry) {
+				order = append(order, "drop")
+				if e.Message == "healthcheck" {
+					e.Drop()
+				}
+			},
+			func(e *LogEntry) {
+				order = append(order, "tag")
+				e.Tags = "transformed:true"
+			},
+		},
+		DefaultFields: map[string]any{"usr": "alice"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "login"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "healthcheck"))
+	_ = writer.Close()
+
+	if got := strings.Join(order, ","); got != "rename,drop,tag,rename,drop" {
+		t.Errorf("transform order = %s, want rename,drop,tag,rename,drop", got)
+	}
+	if strings.Contains(out.String(), "healthcheck") {
+		t.Errorf("dropped entry was written: %s", out.String())
+	}
+	if !strings.Contains(out.String(), `"user":"alice"`) || !strings.Contains(out.String(), `"ddtags":"transformed:true"`) {
+		t.Errorf("output = %s, want renamed field and derived tags", out.String())
+	}
+	if stats := writer.Stats(); stats.EntriesTransformDropped != 1 || stats.EntriesDropped != 1 {
+		t.Errorf("EntriesTransformDropped = %d, EntriesDropped = %d, want 1 and 1", stats.EntriesTransformDropped, stats.EntriesDropped)
+	}
+}
+
+func TestWriter_ClientIPField(t *testing.T) {
+	writer := &Writer{config: Config{
+		ClientIPField: "client_ip",
+		DefaultFields: map[string]any{"network": map[string]any{"bytes_read": 512}},
+	}}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"203.0.113.7", "203.0.113.7"},
+		{" 2001:db8::1 ", "2001:db8::1"},
+		{"not-an-ip", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		record := iris.NewRecord(iris.Info, "request")
+		record.AddField(iris.Str("client_ip", tt.value))
+		entry := writer.buildLogEntry(record)
+
+		network, _ := entry.Fields["network"].(map[string]any)
+		if network["bytes_read"] != 512 {
+			t.Errorf("%q: existing network attributes lost: %v", tt.value, network)
+		}
+		client, _ := network["client"].(map[string]any)
+		got, _ := client["ip"].(string)
+		if got != tt.want {
+			t.Errorf("%q: network.client.ip = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+	if got := writer.Stats().InvalidClientIPs; got != 2 {
+		t.Errorf("InvalidClientIPs = %d, want 2", got)
+	}
+}
+
+func TestWriter_MaxLifetimeRequests(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		mu.Lock()
+		batches = append(batches, len(entries))
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:              "test-key",
+		Site:                strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:           1,
+		FlushInterval:       time.Hour,
+		MaxLifetimeRequests: 2,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("job step %d", i)))
+	}
+	_ = writer.Flush()
+	if got := writer.Stats().LifetimeRequestsRemaining; got != 0 {
+		t.Errorf("LifetimeRequestsRemaining = %d, want 0", got)
+	}
+
+	mu.Lock()
+	if len(batches) != 2 {
+		t.Errorf("requests before Close = %d, want 2", len(batches))
+	}
+	mu.Unlock()
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 3 || batches[2] != 3 {
+		t.Errorf("batches = %v, want the remaining 3 entries sent together by Close", batches)
+	}
+}
+
+func TestWriter_MaxLifetimeRequestsSplitBatch(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		mu.Lock()
+		batches = append(batches, len(entries))
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:              "test-key",
+		Site:                strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:           10000,
+		FlushInterval:       time.Hour,
+		MaxLifetimeRequests: 2,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// One flush split into three sub-batches, one more than the cap allows
+	const records = 2*maxEntriesPerRequest + 10
+	for i := 0; i < records; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("job step %d", i)))
+	}
+	_ = writer.Flush()
+
+	mu.Lock()
+	if len(batches) != 2 {
+		t.Errorf("requests before Close = %d, want 2", len(batches))
+	}
+	mu.Unlock()
+	if got := writer.Stats().Requests; got != 2 {
+		t.Errorf("Requests = %d, want 2", got)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for _, n := range batches {
+		total += n
+	}
+	if total != records || writer.Stats().EntriesSent != records {
+		t.Errorf("entries received = %d, stats = %+v, want all %d delivered with the rest sent by Close", total, writer.Stats(), records)
+	}
+}
+
+func TestWriter_EmitEntryChecksum(t *testing.T) {
+	writer, err := New(Config{
+		Output:            OutputStdout,
+		OutputWriter:      io.Discard,
+		EmitEntryChecksum: true,
+		DefaultFields:     map[string]any{"user": "alice", "amount": 42},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Info, "transfer"))
+	if err := writer.stampChecksum(&entry); err != nil {
+		t.Fatalf("stampChecksum() error = %v", err)
+	}
+	got, _ := entry.Fields[checksumKey].(string)
+
+	digest := sha256.Sum256([]byte(`{"amount":42,"message":"transfer","user":"alice"}`))
+	if want := fmt.Sprintf("%x", digest[:defaultChecksumBytes]); got != want {
+		t.Errorf("dd.checksum = %q, want %q", got, want)
+	}
+
+	other := writer.buildLogEntry(iris.NewRecord(iris.Info, "transfer!"))
+	_ = writer.stampChecksum(&other)
+	if other.Fields[checksumKey] == got {
+		t.Error("different messages produced the same checksum")
+	}
+
+	writer.config.ChecksumHash = sha512.New
+	writer.config.ChecksumBytes = 64
+	delete(entry.Fields, checksumKey)
+	_ = writer.stampChecksum(&entry)
+	if long, _ := entry.Fields[checksumKey].(string); len(long) != 128 {
+		t.Errorf("SHA-512 checksum = %q, want 128 hex characters", long)
+	}
+}
+
+func TestWriter_ChecksumMatchesShippedEntry(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:          true,
+		FlushInterval:        time.Hour,
+		EmitEntryChecksum:    true,
+		CoalesceDuplicates:   true,
+		CorrelationAttribute: "flush_id",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Warn, "disk almost full"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Warn, "disk almost full"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	batches := writer.CapturedBatches()
+	if len(batches) != 1 || len(batches[0].Entries) != 1 {
+		t.Fatalf("batches = %+v, want one coalesced entry", batches)
+	}
+	entry := batches[0].Entries[0]
+	if entry.Fields[repeatCountKey] == nil || entry.Fields["flush_id"] == nil {
+		t.Fatalf("fields = %v, want repeat count and correlation ID", entry.Fields)
+	}
+
+	content := map[string]any{"message": entry.Message}
+	for key, value := range entry.Fields {
+		if key != checksumKey {
+			content[key] = value
+		}
+	}
+	data, _ := json.Marshal(content)
+	digest := sha256.Sum256(data)
+	if want := fmt.Sprintf("%x", digest[:defaultChecksumBytes]); entry.Fields[checksumKey] != want {
+		t.Errorf("dd.checksum = %v, want %s over %s", entry.Fields[checksumKey], want, data)
+	}
+}
+
+func TestWriter_EmitISOTimestamp(t *testing.T) {
+	var out strings.Builder
+	writer, err := New(Config{
+		Output:           OutputStdout,
+		OutputWriter:     &out,
+		EmitISOTimestamp: true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	at := time.Date(2025, 9, 6, 12, 15, 30, 123456789, time.FixedZone("CEST", 2*3600))
+	_ = writer.WriteRecordAt(at, iris.NewRecord(iris.Info, "backfill"))
+	_ = writer.Close()
+
+	var entry struct {
+		Timestamp int64  `json:"timestamp"`
+		ISO       string `json:"dd.timestamp_iso"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &entry); err != nil {
+		t.Fatalf("invalid output %q: %v", out.String(), err)
+	}
+	if entry.ISO != "2025-09-06T10:15:30.123Z" {
+		t.Errorf("dd.timestamp_iso = %q, want 2025-09-06T10:15:30.123Z", entry.ISO)
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, entry.ISO)
+	if err != nil || parsed.UnixMilli() != entry.Timestamp {
+		t.Errorf("ISO %q and timestamp %d disagree", entry.ISO, entry.Timestamp)
+	}
+}
+
+func TestWriter_InitialBufferCapacity(t *testing.T) {
+	writer, err := New(Config{APIKey: "test-api-key", BatchSize: 100, InitialBufferCapacity: 250, DoubleBuffer: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	if cap(writer.buffer) != 250 || cap(writer.spare) != 250 {
+		t.Errorf("buffer capacities = %d, %d, want 250", cap(writer.buffer), cap(writer.spare))
+	}
+
+	burst := make([]LogEntry, 0, 2000)
+	if got := cap(writer.recycleBuffer(burst)); got != 250 {
+		t.Errorf("recycled burst capacity = %d, want a fresh 250-entry buffer", got)
+	}
+	kept := make([]LogEntry, 10, 500)
+	if got := writer.recycleBuffer(kept); cap(got) != 500 || len(got) != 0 {
+		t.Errorf("recycled buffer len/cap = %d/%d, want 0/500", len(got), cap(got))
+	}
+}
+
+// benchmarkOverlappingFlushes fills the buffer past BatchSize (as deferred
+// flushes and cooldowns do) and takes it twice before either batch is
+// released, so every other cycle starts on a freshly allocated buffer
+func benchmarkOverlappingFlushes(b *testing.B, initialCapacity int) {
+	writer := &Writer{config: Config{BatchSize: 1000, InitialBufferCapacity: initialCapacity, DoubleBuffer: true}}
+	writer.buffer = writer.newBuffer()
+	writer.spare = writer.newBuffer()
+	entry := LogEntry{Level: "info", Message: "benchmark"}
+	fill := func() {
+		for j := 0; j < 1500; j++ {
+			writer.buffer = append(writer.buffer, entry)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fill()
+		first := writer.takeBuffer()
+		fill()
+		second := writer.takeBuffer()
+		writer.releaseBuffer(first)
+		writer.releaseBuffer(second)
+	}
+}
+
+func BenchmarkOverlappingFlushes_BatchSizeCapacity(b *testing.B) {
+	benchmarkOverlappingFlushes(b, 1000)
+}
+
+func BenchmarkOverlappingFlushes_InitialBufferCapacity(b *testing.B) {
+	benchmarkOverlappingFlushes(b, 2048)
+}
+
+func TestWriter_HTTPMethod(t *testing.T) {
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:     "test-api-key",
+		Site:       strings.TrimPrefix(server.URL, "http://"),
+		HTTPMethod: "put",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "via gateway"))
+	_ = writer.Close()
+	if method != http.MethodPut {
+		t.Errorf("method = %q, want PUT", method)
+	}
+
+	for _, invalid := range []string{"GET", "HEAD", "DELETE"} {
+		if _, err := New(Config{APIKey: "test-api-key", HTTPMethod: invalid}); err == nil {
+			t.Errorf("New(HTTPMethod: %q) should fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// debug.go: Request introspection for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RequestInfo describes the intake request the writer would issue, with
+// the API key redacted everywhere it appears
+type RequestInfo struct {
+	// Method is the HTTP method used for intake requests
+	Method string
+
+	// URL is the intake URL with the API key redacted
+	URL string
+
+	// Headers are the request headers with the API key redacted
+	Headers map[string]string
+
+	// Compression reports whether request bodies are gzip-compressed
+	Compression bool
+}
+
+// DebugRequestInfo returns the URL and headers the writer will use for
+// intake requests without sending anything. The Content-Type is the one
+// of the configured Serializer and the correlation header, if any, shows
+// a freshly generated ID. The API key is never included in full, so the
+// result is safe to log at startup.
+func (w *Writer) DebugRequestInfo() RequestInfo {
+	request := intakeRequest{
+		url:           w.intakeURL(),
+		contentType:   contentTypeJSON,
+		correlationID: w.correlationID(),
+	}
+	if _, contentType, err := w.serialize(nil); err == nil && contentType != "" {
+		request.contentType = contentType
+	}
+	if w.config.EnableCompression {
+		request.contentEncoding = "gzip"
+	}
+
+	header := make(http.Header)
+	w.setIntakeHeaders(header, request)
+
+	redacted := redactKey(w.config.APIKey)
+	headers := make(map[string]string, len(header))
+	for key := range header {
+		headers[key] = header.Get(key)
+		if w.config.APIKey != "" {
+			headers[key] = strings.ReplaceAll(headers[key], w.config.APIKey, redacted)
+		}
+	}
+
+	url := request.url
+	if w.config.APIKey != "" {
+		url = strings.ReplaceAll(url, w.config.APIKey, redacted)
+	}
+
+	return RequestInfo{
+		Method:      w.config.HTTPMethod,
+		URL:         url,
+		Headers:     headers,
+		Compression: w.config.EnableCompression,
+	}
+}
+
+// redactKey masks a secret, keeping at most a two character prefix so
+// keys can be told apart without being disclosed
+func redactKey(key string) string {
+	if len(key) < 8 {
+		return "***"
+	}
+	return key[:2] + "***"
+}
+
+// EffectiveConfig returns the configuration in use after defaults, agent
+// environment and profiles were applied, with API keys redacted. Print it
+// with %v (see Config.String) to log it at startup safely.
+func (w *Writer) EffectiveConfig() Config {
+	return w.config.redacted()
+}
+
+// String renders the configuration with API keys redacted and functions,
+// including those in slices such as Transforms, shown as <set> or <nil>,
+// so a Config can be logged safely
+func (c Config) String() string {
+	c = c.redacted()
+	value := reflect.ValueOf(c)
+	typ := value.Type()
+
+	var b strings.Builder
+	b.WriteString("Config{")
+	for i := 0; i < typ.NumField(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(typ.Field(i).Name)
+		b.WriteString(": ")
+
+		field := value.Field(i)
+		switch field.Kind() {
+		case reflect.Func:
+			writeFunc(&b, field)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.Func {
+				fmt.Fprintf(&b, "%v", field.Interface())
+				break
+			}
+			b.WriteByte('[')
+			for j := 0; j < field.Len(); j++ {
+				if j > 0 {
+					b.WriteByte(' ')
+				}
+				writeFunc(&b, field.Index(j))
+			}
+			b.WriteByte(']')
+		case reflect.Interface:
+			if field.IsNil() {
+				b.WriteString("<nil>")
+			} else {
+				fmt.Fprintf(&b, "%T", field.Interface())
+			}
+		default:
+			fmt.Fprintf(&b, "%v", field.Interface())
+		}
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// writeFunc renders a function value as <set> or <nil>, never its address
+func writeFunc(b *strings.Builder, fn reflect.Value) {
+	if fn.IsNil() {
+		b.WriteString("<nil>")
+	} else {
+		b.WriteString("<set>")
+	}
+}
+
+// redacted returns a copy of c with the API key, profile keys and
+// destination keys masked. Already redacted keys are left as they are.
+func (c Config) redacted() Config {
+	c.APIKey = redactConfigKey(c.APIKey)
+	if len(c.Profiles) > 0 {
+		profiles := make(map[string]Profile, len(c.Profiles))
+		for name, profile := range c.Profiles {
+			profile.APIKey = redactConfigKey(profile.APIKey)
+			profiles[name] = profile
+		}
+		c.Profiles = profiles
+	}
+	if len(c.AdditionalDestinations) > 0 {
+		destinations := make([]DestinationConfig, len(c.AdditionalDestinations))
+		for i, destination := range c.AdditionalDestinations {
+			destination.APIKey = redactConfigKey(destination.APIKey)
+			destinations[i] = destination
+		}
+		c.AdditionalDestinations = destinations
+	}
+	return c
+}
+
+// redactConfigKey redacts a configured key, keeping empty and already
+// redacted values unchanged
+func redactConfigKey(key string) string {
+	if key == "" || strings.HasSuffix(key, "***") {
+		return key
+	}
+	return redactKey(key)
+}
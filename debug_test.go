@@ -0,0 +1,157 @@
+// debug_test.go: Request introspection tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriter_DebugRequestInfo(t *testing.T) {
+	const apiKey = "abcdef0123456789"
+
+	writer, err := New(Config{
+		APIKey:            apiKey,
+		Site:              "datadoghq.eu",
+		EnableCompression: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	info := writer.DebugRequestInfo()
+
+	if info.URL != "https://http-intake.logs.datadoghq.eu/v1/input/ab***" {
+		t.Errorf("URL = %q", info.URL)
+	}
+	if info.Headers["Dd-Api-Key"] != "ab***" {
+		t.Errorf("DD-API-KEY header = %q, want redacted", info.Headers["Dd-Api-Key"])
+	}
+	if info.Headers["Content-Encoding"] != "gzip" || !info.Compression {
+		t.Error("Expected compression to be reported")
+	}
+
+	for key, value := range info.Headers {
+		if strings.Contains(value, apiKey) {
+			t.Errorf("Header %s leaks the API key", key)
+		}
+	}
+	if strings.Contains(info.URL, apiKey) {
+		t.Error("URL leaks the API key")
+	}
+}
+
+func TestWriter_DebugRequestInfoEmptyKey(t *testing.T) {
+	writer, err := New(Config{CaptureMode: true})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	info := writer.DebugRequestInfo()
+	if got := info.Headers["Content-Type"]; got != contentTypeJSON {
+		t.Errorf("Content-Type = %q, want %q unchanged without an API key", got, contentTypeJSON)
+	}
+	if got := info.Headers["Dd-Api-Key"]; got != "" {
+		t.Errorf("DD-API-KEY header = %q, want empty", got)
+	}
+}
+
+func TestRedactKey(t *testing.T) {
+	if got := redactKey("short"); got != "***" {
+		t.Errorf("redactKey(short) = %q, want fully masked", got)
+	}
+	if got := redactKey("abcdef0123456789"); got != "ab***" {
+		t.Errorf("redactKey() = %q, want %q", got, "ab***")
+	}
+}
+
+func TestWriter_EffectiveConfig(t *testing.T) {
+	const apiKey = "abcdef0123456789"
+
+	writer, err := New(Config{
+		APIKey:   apiKey,
+		OnError:  func(error) {},
+		Profiles: map[string]Profile{"prod": {APIKey: "prod0123456789"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	config := writer.EffectiveConfig()
+	if config.APIKey != "ab***" || config.Profiles["prod"].APIKey != "pr***" {
+		t.Errorf("APIKey/profile key = %q/%q, want redacted", config.APIKey, config.Profiles["prod"].APIKey)
+	}
+	if config.BatchSize != 1000 || config.Site != "datadoghq.com" || config.FlushInterval.String() != "1s" {
+		t.Errorf("Expected defaults to be applied, got %v", config)
+	}
+	if writer.config.APIKey != apiKey {
+		t.Error("EffectiveConfig() must not modify the writer's config")
+	}
+
+	text := config.String()
+	for _, want := range []string{"APIKey: ab***", "BatchSize: 1000", "OnError: <set>", "OnResponse: <nil>", "OutputWriter: *os.File"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("String() missing %q: %s", want, text)
+		}
+	}
+	if strings.Contains(text, apiKey) || strings.Contains(Config{APIKey: apiKey}.String(), apiKey) {
+		t.Error("String() leaks the API key")
+	}
+}
+
+func TestConfig_RedactsDestinationKeys(t *testing.T) {
+	const secondKey = "SECOND-ORG-SECRET-KEY"
+
+	config := Config{
+		APIKey:                 "abcdef0123456789",
+		AdditionalDestinations: []DestinationConfig{{Site: "datadoghq.eu", APIKey: secondKey}},
+	}
+	redacted := config.redacted()
+	if got := redacted.AdditionalDestinations[0].APIKey; got != "SE***" {
+		t.Errorf("destination APIKey = %q, want SE***", got)
+	}
+	if config.AdditionalDestinations[0].APIKey != secondKey {
+		t.Error("redacted() must not modify the original destinations")
+	}
+	if text := config.String(); strings.Contains(text, secondKey) {
+		t.Errorf("String() leaks the destination API key: %s", text)
+	}
+}
+
+func TestConfig_StringTransforms(t *testing.T) {
+	config := Config{Transforms: []func(*LogEntry){func(*LogEntry) {}, nil}}
+	text := config.String()
+	if !strings.Contains(text, "Transforms: [<set> <nil>]") {
+		t.Errorf("String() = %s, want Transforms: [<set> <nil>]", text)
+	}
+	if strings.Contains(text, "0x") {
+		t.Errorf("String() prints function addresses: %s", text)
+	}
+}
+
+func TestWriter_DebugRequestInfoMatchesRequest(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:            "abcdef0123456789",
+		Serializer:        NDJSONSerializer{},
+		CorrelationHeader: "X-Batch-Id",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	info := writer.DebugRequestInfo()
+	if got := info.Headers["Content-Type"]; got != contentTypeNDJSON {
+		t.Errorf("Content-Type = %q, want the serializer's %q", got, contentTypeNDJSON)
+	}
+	if got := info.Headers["X-Batch-Id"]; got == "" {
+		t.Error("X-Batch-Id header missing, want the configured CorrelationHeader")
+	}
+}
@@ -0,0 +1,90 @@
+// debugring.go: In-memory ring of recent entries for on-box debugging
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// debugRing keeps the last entries the writer processed, whether or not
+// they reached Datadog
+type debugRing struct {
+	mutex   sync.Mutex
+	entries []LogEntry
+	next    int  // Slot the next entry is written to
+	full    bool // Whether entries has wrapped around
+}
+
+// newDebugRing returns a ring of size entries, or nil when size <= 0
+func newDebugRing(size int) *debugRing {
+	if size <= 0 {
+		return nil
+	}
+	return &debugRing{entries: make([]LogEntry, size)}
+}
+
+// add records a copy of entry, overwriting the oldest one when full
+func (r *debugRing) add(entry LogEntry) {
+	if r == nil {
+		return
+	}
+	entry = copyEntries([]LogEntry{entry})[0]
+
+	r.mutex.Lock()
+	r.entries[r.next] = entry
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	r.mutex.Unlock()
+}
+
+// snapshot returns the retained entries, oldest first
+func (r *debugRing) snapshot() []LogEntry {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.full {
+		return copyEntries(r.entries[:r.next])
+	}
+	return copyEntries(append(r.entries[r.next:len(r.entries):len(r.entries)], r.entries[:r.next]...))
+}
+
+// DebugHandler returns an http.Handler that serves the last
+// Config.DebugRingSize entries as a JSON array, oldest first. Entries are
+// shown exactly as they are sent to Datadog, with no extra redaction, so
+// mount the handler on an internal or loopback-only listener.
+func (w *Writer) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			rw.Header().Set("Allow", "GET, HEAD")
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if w.ring == nil {
+			http.Error(rw, "debug ring disabled: set Config.DebugRingSize", http.StatusNotFound)
+			return
+		}
+
+		entries := w.ring.snapshot()
+		if entries == nil {
+			entries = []LogEntry{}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(rw).Encode(entries); err != nil {
+			w.handleError(fmt.Errorf("debug handler: %w", err))
+		}
+	})
+}
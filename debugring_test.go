@@ -0,0 +1,60 @@
+// debugring_test.go: Debug ring and handler tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_DebugHandler(t *testing.T) {
+	writer, err := New(Config{CaptureMode: true, BatchSize: 2, DebugRingSize: 3})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	for i := 0; i < 5; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("message %d", i)))
+	}
+
+	rec := httptest.NewRecorder()
+	writer.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/datadog", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("response = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var entries []LogEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+	}
+	var messages []string
+	for _, entry := range entries {
+		messages = append(messages, entry.Message)
+	}
+	if fmt.Sprint(messages) != "[message 2 message 3 message 4]" {
+		t.Errorf("messages = %v, want the last 3, oldest first", messages)
+	}
+
+	rec = httptest.NewRecorder()
+	writer.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/datadog", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
+
+func TestWriter_DebugHandlerDisabled(t *testing.T) {
+	writer := &Writer{}
+	rec := httptest.NewRecorder()
+	writer.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without DebugRingSize", rec.Code)
+	}
+}
@@ -0,0 +1,123 @@
+// destinations.go: Fan-out of log entries to additional Datadog orgs
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+// DestinationConfig describes an extra Datadog org that receives a copy of
+// the writer's logs
+type DestinationConfig struct {
+	// Site is the Datadog site of the destination (default: Config.Site)
+	Site string
+
+	// APIKey is the destination org's API key (required)
+	APIKey string
+
+	// Tags are merged over Config.Tags for this destination
+	Tags map[string]string
+
+	// MinLevel is the lowest level sent to the destination. The zero
+	// value is iris.Info; use iris.Debug to forward everything.
+	MinLevel iris.Level
+}
+
+// destination is an additional org with its own writer, so it batches,
+// retries and fails independently of the primary
+type destination struct {
+	name     string
+	minLevel iris.Level
+	writer   *Writer
+}
+
+// newDestinations builds a writer per Config.AdditionalDestinations entry,
+// inheriting every other setting from the primary config
+func newDestinations(config Config) ([]*destination, error) {
+	destinations := make([]*destination, 0, len(config.AdditionalDestinations))
+	for i, dc := range config.AdditionalDestinations {
+		if dc.APIKey == "" && !config.CaptureMode {
+			closeDestinations(destinations)
+			return nil, fmt.Errorf("additional destination %d: API key is required", i)
+		}
+
+		child := config
+		child.Output = OutputIntake
+		child.APIKey = dc.APIKey
+		child.Profiles = nil
+		child.ActiveProfile = ""
+		child.AdditionalDestinations = nil
+		if config.WALDir != "" {
+			child.WALDir = filepath.Join(config.WALDir, fmt.Sprintf("destination-%d", i))
+		}
+		if dc.Site != "" {
+			child.Site = dc.Site
+		}
+		if len(dc.Tags) > 0 {
+			child.Tags = make(map[string]string, len(config.Tags)+len(dc.Tags))
+			for key, value := range config.Tags {
+				child.Tags[key] = value
+			}
+			for key, value := range dc.Tags {
+				child.Tags[key] = value
+			}
+		}
+
+		name := fmt.Sprintf("%d (%s)", i, child.Site)
+		if config.OnError != nil {
+			onError := config.OnError
+			child.OnError = func(err error) {
+				onError(fmt.Errorf("destination %s: %w", name, err))
+			}
+		}
+
+		writer, err := New(child)
+		if err != nil {
+			closeDestinations(destinations)
+			return nil, fmt.Errorf("additional destination %d: %w", i, err)
+		}
+		destinations = append(destinations, &destination{name: name, minLevel: dc.MinLevel, writer: writer})
+	}
+	return destinations, nil
+}
+
+// fanOut writes record to every destination whose MinLevel it meets. A
+// destination's errors are reported to OnError and never affect the
+// primary or the other destinations.
+func (w *Writer) fanOut(record *iris.Record, at time.Time) {
+	for _, d := range w.destinations {
+		if record.Level < d.minLevel {
+			continue
+		}
+		if err := d.writer.writeRecord(record, at); err != nil && !errors.Is(err, ErrWriterClosed) {
+			w.handleError(fmt.Errorf("destination %s: %w", d.name, err))
+		}
+	}
+}
+
+// flushDestinations flushes every destination and joins their errors
+func (w *Writer) flushDestinations() error {
+	errs := make([]error, len(w.destinations))
+	for i, d := range w.destinations {
+		errs[i] = d.writer.Flush()
+	}
+	return errors.Join(errs...)
+}
+
+// closeDestinations closes every destination and joins their errors
+func closeDestinations(destinations []*destination) error {
+	errs := make([]error, len(destinations))
+	for i, d := range destinations {
+		errs[i] = d.writer.Close()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+// destinations_test.go: Fan-out to additional Datadog orgs tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_AdditionalDestinations(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:        "primary-key",
+		CaptureMode:   true,
+		Tags:          map[string]string{"team": "payments"},
+		FlushInterval: time.Hour,
+		AdditionalDestinations: []DestinationConfig{{
+			Site:     "datadoghq.eu",
+			APIKey:   "security-key",
+			Tags:     map[string]string{"org": "security"},
+			MinLevel: iris.Warn,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "checkout"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "card declined"))
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	primary := writer.CapturedBatches()
+	if len(primary) != 1 || len(primary[0].Entries) != 2 {
+		t.Fatalf("primary batches = %+v, want one batch of 2", primary)
+	}
+	security := writer.destinations[0].writer.CapturedBatches()
+	if len(security) != 1 || len(security[0].Entries) != 1 {
+		t.Fatalf("destination batches = %+v, want one batch of 1", security)
+	}
+	entry := security[0].Entries[0]
+	if entry.Message != "card declined" || !strings.Contains(entry.Tags, "org:security") || !strings.Contains(entry.Tags, "team:payments") {
+		t.Errorf("destination entry = %+v, want the error with merged tags", entry)
+	}
+	if key := security[0].Headers.Get("DD-API-KEY"); key != "security-key" {
+		t.Errorf("destination DD-API-KEY = %q, want security-key", key)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := writer.destinations[0].writer.WriteRecord(iris.NewRecord(iris.Error, "late")); err != ErrWriterClosed {
+		t.Errorf("destination WriteRecord after Close = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestWriter_AdditionalDestinationFailureIsolated(t *testing.T) {
+	var primaryLogs atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryLogs.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer primary.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer broken.Close()
+
+	var reported atomic.Int32
+	writer, err := New(Config{
+		APIKey:        "primary-key",
+		Site:          strings.TrimPrefix(primary.URL, "http://"),
+		FlushInterval: time.Hour,
+		OnError: func(err error) {
+			if strings.Contains(err.Error(), "destination 0") {
+				reported.Add(1)
+			}
+		},
+		AdditionalDestinations: []DestinationConfig{{
+			Site:     strings.TrimPrefix(broken.URL, "http://"),
+			APIKey:   "other-key",
+			MinLevel: iris.Debug,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "hello"))
+	_ = writer.Close()
+
+	if primaryLogs.Load() != 1 {
+		t.Errorf("primary requests = %d, want 1", primaryLogs.Load())
+	}
+	if writer.Stats().EntriesSent != 1 {
+		t.Errorf("primary EntriesSent = %d, want 1", writer.Stats().EntriesSent)
+	}
+	if reported.Load() == 0 {
+		t.Error("destination failure was not reported to OnError")
+	}
+}
+
+func TestNew_AdditionalDestinationRequiresAPIKey(t *testing.T) {
+	_, err := New(Config{
+		APIKey:                 "primary-key",
+		AdditionalDestinations: []DestinationConfig{{Site: "datadoghq.eu"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "API key is required") {
+		t.Errorf("New() error = %v, want missing API key", err)
+	}
+}
@@ -0,0 +1,59 @@
+// dns.go: Fast-fail circuit for unresolvable intake hosts
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrDNSUnresolvable is returned for batches failed fast while the intake
+// host cannot be resolved (see Config.DNSFailureThreshold)
+var ErrDNSUnresolvable = errors.New("datadog intake DNS unresolvable")
+
+// isDNSFailure reports whether err comes from resolving the intake host
+func isDNSFailure(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// observeDNS counts consecutive DNS failures and opens the DNS circuit
+// once Config.DNSFailureThreshold is reached. Any request that got past
+// resolution closes it again. It reports whether the circuit is now open.
+func (w *Writer) observeDNS(err error) bool {
+	if w.config.DNSFailureThreshold <= 0 {
+		return false
+	}
+	// One circuit per writer: partitions share their parent's
+	root := w.root()
+	if err == nil || !isDNSFailure(err) {
+		root.dnsFailures.Store(0)
+		root.dnsOpenUntil.Store(0)
+		return false
+	}
+	if root.dnsFailures.Add(1) < int64(w.config.DNSFailureThreshold) {
+		return false
+	}
+	root.dnsOpenUntil.Store(time.Now().Add(w.config.DNSCooldown).UnixNano())
+	return true
+}
+
+// dnsCircuitOpen reports whether sends fail fast because the intake host
+// was recently unresolvable
+func (w *Writer) dnsCircuitOpen() bool {
+	return time.Now().UnixNano() < w.root().dnsOpenUntil.Load()
+}
+
+// dnsError is the error reported for a batch failed by the DNS circuit
+func (w *Writer) dnsError(cause error) error {
+	if cause == nil {
+		return fmt.Errorf("%w: %s (retrying after %v)", ErrDNSUnresolvable, w.intakeBaseURL(), w.config.DNSCooldown)
+	}
+	return fmt.Errorf("%w: %w", ErrDNSUnresolvable, cause)
+}
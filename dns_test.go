@@ -0,0 +1,72 @@
+// dns_test.go: DNS fast-fail circuit tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_DNSCircuitOpensOnUnresolvableHost(t *testing.T) {
+	var errs []error
+	writer, err := New(Config{
+		APIKey:              "test-api-key",
+		Site:                "unresolvable.invalid",
+		BatchSize:           1,
+		MaxRetries:          5,
+		RetryDelay:          time.Millisecond,
+		DNSFailureThreshold: 2,
+		DNSCooldown:         time.Minute,
+		OnError:             func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "first"))
+	if got := writer.Stats().Requests; got != 2 {
+		t.Errorf("Requests = %d, want the circuit to open after 2 lookups", got)
+	}
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "second"))
+	stats := writer.Stats()
+	if stats.Requests != 2 {
+		t.Errorf("Requests = %d, want no request while the circuit is open", stats.Requests)
+	}
+	if stats.DNSFastFails != 1 {
+		t.Errorf("DNSFastFails = %d, want 1", stats.DNSFastFails)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("OnError called %d times, want 2: %v", len(errs), errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrDNSUnresolvable) {
+			t.Errorf("error = %v, want ErrDNSUnresolvable", err)
+		}
+	}
+}
+
+func TestWriter_DNSCircuitResetsOnResolution(t *testing.T) {
+	writer := &Writer{config: Config{DNSFailureThreshold: 1, DNSCooldown: time.Minute}}
+
+	lookup := fmt.Errorf("failed to send request: %w", &net.DNSError{Err: "no such host", IsNotFound: true})
+	if !writer.observeDNS(lookup) || !writer.dnsCircuitOpen() {
+		t.Fatal("circuit should open at the threshold")
+	}
+	if writer.observeDNS(errors.New("connection refused")) || writer.dnsCircuitOpen() {
+		t.Error("a resolved host should close the circuit")
+	}
+	if writer.dnsFailures.Load() != 0 {
+		t.Errorf("dnsFailures = %d, want reset to 0", writer.dnsFailures.Load())
+	}
+}
@@ -47,6 +47,18 @@
 //   - Implements retry logic with exponential backoff
 //   - Thread-safe for concurrent logging operations
 //
+// # Compression
+//
+// EnableCompression gzip-compresses each request body, which typically
+// shrinks log-shaped JSON several times over. Every request is an
+// independent gzip stream; custom or pre-shared compression dictionaries
+// are not supported, because the Datadog intake has no way to receive a
+// dictionary and could not decode bodies compressed with one.
+//
+// Request bodies are always JSON. The Datadog HTTP logs intake does not
+// accept a protobuf encoding, so there is no Encoding option; compression
+// is the supported way to reduce payload size.
+//
 // # Error Handling
 //
 // The writer includes comprehensive error handling:
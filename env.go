@@ -0,0 +1,63 @@
+// env.go: Datadog Agent environment variable conventions
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"os"
+	"strings"
+)
+
+// applyAgentEnv fills empty Config fields from the DD_ENV, DD_SERVICE,
+// DD_VERSION and DD_TAGS variables used by the Datadog Agent and tracers.
+// Values set in code always win; DD_TAGS entries are merged into Tags
+// only for keys that are not already present.
+func applyAgentEnv(config *Config) {
+	if config.Environment == "" {
+		config.Environment = os.Getenv("DD_ENV")
+	}
+	if config.Service == "" {
+		config.Service = os.Getenv("DD_SERVICE")
+	}
+	if config.Version == "" {
+		config.Version = os.Getenv("DD_VERSION")
+	}
+
+	parsed := parseDDTags(os.Getenv("DD_TAGS"))
+	if len(parsed) == 0 {
+		return
+	}
+
+	tags := make(map[string]string, len(config.Tags)+len(parsed))
+	for key, value := range parsed {
+		tags[key] = value
+	}
+	for key, value := range config.Tags {
+		tags[key] = value
+	}
+	config.Tags = tags
+}
+
+// parseDDTags parses a DD_TAGS value. Like the Agent, it accepts tags
+// separated by commas or whitespace; tags without a value are kept with
+// an empty value and rendered bare.
+func parseDDTags(value string) map[string]string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string, len(fields))
+	for _, field := range fields {
+		key, val, _ := strings.Cut(field, ":")
+		if key != "" {
+			tags[key] = val
+		}
+	}
+	return tags
+}
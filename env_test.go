@@ -0,0 +1,56 @@
+// env_test.go: Datadog Agent environment variable convention tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+)
+
+func TestNew_InheritAgentEnv(t *testing.T) {
+	t.Setenv("DD_ENV", "staging")
+	t.Setenv("DD_SERVICE", "env-service")
+	t.Setenv("DD_VERSION", "2.0.0")
+	t.Setenv("DD_TAGS", "team:core, region:eu-west-1 canary")
+
+	writer, err := New(Config{
+		APIKey:          "test-api-key",
+		Service:         "code-service",
+		Tags:            map[string]string{"region": "us-east-1"},
+		InheritAgentEnv: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if writer.config.Service != "code-service" {
+		t.Errorf("Service = %q, code config must win", writer.config.Service)
+	}
+	if writer.config.Environment != "staging" || writer.config.Version != "2.0.0" {
+		t.Errorf("Environment/Version = %q/%q, want inherited values",
+			writer.config.Environment, writer.config.Version)
+	}
+
+	want := "canary,region:us-east-1,team:core"
+	if got := writer.currentTags().ddtags; got != want {
+		t.Errorf("ddtags = %q, want %q", got, want)
+	}
+}
+
+func TestNew_AgentEnvIgnoredByDefault(t *testing.T) {
+	t.Setenv("DD_ENV", "staging")
+
+	writer, err := New(Config{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if writer.config.Environment != "" {
+		t.Errorf("Environment = %q, want empty without InheritAgentEnv", writer.config.Environment)
+	}
+}
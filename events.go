@@ -0,0 +1,200 @@
+// events.go: Datadog Events API mirroring for high-severity logs
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/agilira/iris"
+)
+
+const (
+	// maxEventTitleBytes and maxEventTextBytes are the Events API limits
+	maxEventTitleBytes = 100
+	maxEventTextBytes  = 4000
+
+	// eventQueueSize and eventWorkers bound the events waiting to be
+	// posted and the posts in flight, so an error storm cannot start
+	// unbounded goroutines and connections
+	eventQueueSize = 256
+	eventWorkers   = 4
+)
+
+// event is the Datadog Events API v1 request body
+type event struct {
+	Title          string   `json:"title"`
+	Text           string   `json:"text"`
+	AlertType      string   `json:"alert_type"`
+	Host           string   `json:"host,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	SourceTypeName string   `json:"source_type_name,omitempty"`
+	DateHappened   int64    `json:"date_happened"`
+}
+
+// emitsEvent reports whether a record of this level is mirrored as an
+// event. Thresholds at or below iris.Info disable events.
+func (w *Writer) emitsEvent(level iris.Level) bool {
+	threshold := w.config.EmitEventsAboveLevel
+	return threshold > iris.Info && level >= threshold
+}
+
+// startEventWorkers creates the event queue and its workers. Partitions
+// post through the root writer's queue (see postEventAsync).
+func (w *Writer) startEventWorkers() {
+	w.eventQ = make(chan LogEntry, eventQueueSize)
+	w.events.Add(eventWorkers)
+	for range eventWorkers {
+		go w.runEventWorker(w.eventQ)
+	}
+}
+
+// runEventWorker posts queued events until queue is closed and empty
+func (w *Writer) runEventWorker(queue <-chan LogEntry) {
+	defer w.events.Done()
+	for entry := range queue {
+		if err := w.postEvent(entry); err != nil {
+			w.stats.eventsFailed.Add(1)
+			w.handleError(fmt.Errorf("event: %w", err))
+			continue
+		}
+		w.stats.eventsSent.Add(1)
+	}
+}
+
+// stopEventWorkers closes the event queue and waits for the workers to
+// post what it still holds. Must be called after closed is set.
+func (w *Writer) stopEventWorkers() {
+	w.timerMutex.Lock()
+	if w.eventQ != nil {
+		close(w.eventQ)
+		w.eventQ = nil
+	}
+	w.timerMutex.Unlock()
+	w.events.Wait()
+}
+
+// postEventAsync queues entry for the Events API. When the queue is full
+// the event is dropped and counted in EventsDropped; none are queued
+// after Close.
+func (w *Writer) postEventAsync(entry LogEntry) {
+	root := w.root()
+	root.timerMutex.Lock()
+	defer root.timerMutex.Unlock()
+	if root.closed.Load() || root.eventQ == nil {
+		return
+	}
+	select {
+	case root.eventQ <- entry:
+	default:
+		root.stats.eventsDropped.Add(1)
+	}
+}
+
+// postEvent sends one event built from entry. It is independent of log
+// delivery: no retries, batching or cooldown apply, but the request is
+// signed and takes a MaxConcurrentRequests slot like an intake request.
+// In CaptureMode the event is captured instead of sent.
+func (w *Writer) postEvent(entry LogEntry) error {
+	message := entry.Message
+	if message == "" {
+		message = entry.Level
+	}
+	body, err := json.Marshal(event{
+		Title:          truncateUTF8(firstLine(message), maxEventTitleBytes),
+		Text:           truncateUTF8(message, maxEventTextBytes),
+		AlertType:      alertType(entry.Level),
+		Host:           entry.Hostname,
+		Tags:           eventTags(entry),
+		SourceTypeName: entry.Source,
+		DateHappened:   entry.Timestamp / 1000,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if w.config.CaptureMode {
+		w.captureEvent(entry, body)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", w.eventsURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	w.setRequestHeaders(req.Header, contentTypeJSON, "")
+	if w.config.SignRequest != nil {
+		if err := w.config.SignRequest(req, body); err != nil {
+			return fmt.Errorf("%w: %w", errSigning, err)
+		}
+	}
+
+	if err := w.acquireSlot(); err != nil {
+		return err
+	}
+	defer w.releaseSlot()
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errorBody := readErrorBody(resp.Body, w.config.MaxErrorBodyBytes)
+		return fmt.Errorf("datadog events API error: status %d: %s", resp.StatusCode, errorBody)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// eventsURL builds the Events API URL for the configured site
+func (w *Writer) eventsURL() string {
+	if isLocalSite(w.config.Site) {
+		return "http://" + w.config.Site + "/api/v1/events"
+	}
+	return "https://api." + w.config.Site + "/api/v1/events"
+}
+
+// alertType maps a Datadog log status to an event alert type
+func alertType(status string) string {
+	switch status {
+	case "error", "critical", "alert", "emergency":
+		return "error"
+	case "warn", "warning":
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// eventTags returns the entry's tags, plus service and env, as a list
+func eventTags(entry LogEntry) []string {
+	var tags []string
+	if entry.Tags != "" {
+		tags = strings.Split(entry.Tags, ",")
+	}
+	if entry.Service != "" {
+		tags = append(tags, "service:"+entry.Service)
+	}
+	if entry.Env != "" {
+		tags = append(tags, "env:"+entry.Env)
+	}
+	return tags
+}
+
+// firstLine returns s up to its first newline
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,246 @@
+// events_test.go: Events API mirroring tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_EmitEventsAboveLevel(t *testing.T) {
+	var mu sync.Mutex
+	var events []event
+	var logs int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/v1/events" {
+			var e event
+			_ = json.NewDecoder(r.Body).Decode(&e)
+			events = append(events, e)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		logs++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:               "test-key",
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		Service:              "api",
+		FlushInterval:        time.Hour,
+		EmitEventsAboveLevel: iris.Error,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Warn, "disk at 80%"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "database unreachable\nstack trace"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+	e := events[0]
+	if e.Title != "database unreachable" || e.AlertType != "error" || !strings.Contains(e.Text, "stack trace") {
+		t.Errorf("event = %+v, want title from the first line and alert_type error", e)
+	}
+	if !strings.Contains(strings.Join(e.Tags, ","), "service:api") {
+		t.Errorf("event tags = %v, want service:api", e.Tags)
+	}
+	if logs != 1 || writer.Stats().EntriesSent != 2 || writer.Stats().EventsSent != 1 {
+		t.Errorf("log requests = %d, stats = %+v, want both logs delivered and one event", logs, writer.Stats())
+	}
+}
+
+func TestWriter_EventFailureIsolated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/events" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var reported []error
+	writer, err := New(Config{
+		APIKey:               "test-key",
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:        time.Hour,
+		EmitEventsAboveLevel: iris.Error,
+		OnError: func(err error) {
+			mu.Lock()
+			reported = append(reported, err)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "boom"))
+	if err := writer.Close(); err != nil {
+		t.Errorf("Close() error = %v, want log delivery unaffected", err)
+	}
+
+	stats := writer.Stats()
+	if stats.EntriesSent != 1 || stats.EventsFailed != 1 {
+		t.Errorf("EntriesSent = %d, EventsFailed = %d, want 1 and 1", stats.EntriesSent, stats.EventsFailed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || !strings.HasPrefix(reported[0].Error(), "event:") {
+		t.Errorf("OnError = %v, want one event error", reported)
+	}
+}
+
+func TestWriter_EventsDisabledByDefault(t *testing.T) {
+	writer := &Writer{}
+	if writer.emitsEvent(iris.Fatal) {
+		t.Error("events must be disabled by default")
+	}
+}
+
+func TestWriter_EventStormBounded(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/events" {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			<-release
+			inFlight.Add(-1)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:               "test-key",
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:        time.Hour,
+		EmitEventsAboveLevel: iris.Error,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	const records = eventQueueSize + 100
+	for range records {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Error, "database unreachable"))
+	}
+	close(release)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	stats := writer.Stats()
+	if peak.Load() > eventWorkers {
+		t.Errorf("peak concurrent event posts = %d, want at most %d", peak.Load(), eventWorkers)
+	}
+	if stats.EventsDropped == 0 {
+		t.Errorf("EventsDropped = 0, want events beyond the queue dropped")
+	}
+	if stats.EventsSent+stats.EventsDropped != records {
+		t.Errorf("EventsSent %d + EventsDropped %d != %d, want Close to drain the queue", stats.EventsSent, stats.EventsDropped, records)
+	}
+}
+
+func TestWriter_EventsCaptured(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		CaptureMode:          true,
+		EmitEventsAboveLevel: iris.Error,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "database unreachable"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if n := requests.Load(); n != 0 {
+		t.Errorf("requests = %d, want none in CaptureMode", n)
+	}
+	var events int
+	for _, batch := range writer.CapturedBatches() {
+		if batch.Event {
+			events++
+			if len(batch.Entries) != 1 || batch.Entries[0].Message != "database unreachable" {
+				t.Errorf("captured event entries = %+v, want the error record", batch.Entries)
+			}
+		}
+	}
+	if events != 1 || writer.Stats().EventsSent != 1 {
+		t.Errorf("captured events = %d, EventsSent = %d, want 1", events, writer.Stats().EventsSent)
+	}
+}
+
+func TestWriter_EventsSigned(t *testing.T) {
+	signed := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/events" {
+			signed <- r.Header.Get("X-Signature")
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:                "test-key",
+		Site:                  strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:         time.Hour,
+		MaxConcurrentRequests: 1,
+		EmitEventsAboveLevel:  iris.Error,
+		SignRequest: func(req *http.Request, body []byte) error {
+			req.Header.Set("X-Signature", "signed")
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "database unreachable"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	select {
+	case got := <-signed:
+		if got != "signed" {
+			t.Errorf("X-Signature = %q, want the event request signed", got)
+		}
+	default:
+		t.Fatal("no event received")
+	}
+}
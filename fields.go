@@ -0,0 +1,187 @@
+// fields.go: Custom attribute handling for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+// FieldPolicy controls what happens to attribute values that cannot be
+// encoded as JSON (channels, functions, complex numbers, NaN, ...)
+type FieldPolicy int
+
+const (
+	// FieldPolicyStringify replaces the value with its fmt.Sprintf("%v") form
+	FieldPolicyStringify FieldPolicy = iota
+
+	// FieldPolicyDrop removes the attribute
+	FieldPolicyDrop
+
+	// FieldPolicyError rejects the entry and returns an error from WriteRecord
+	FieldPolicyError
+)
+
+// sanitizeFields makes every attribute value JSON-encodable according to
+// Config.UnsupportedFieldPolicy, so one odd value cannot make the whole
+// batch fail to marshal.
+func (w *Writer) sanitizeFields(fields map[string]any) error {
+	for key, value := range fields {
+		if encodable(value) {
+			continue
+		}
+
+		switch w.config.UnsupportedFieldPolicy {
+		case FieldPolicyDrop:
+			delete(fields, key)
+		case FieldPolicyError:
+			return fmt.Errorf("field %q has unsupported type %T", key, value)
+		default:
+			fields[key] = fmt.Sprintf("%v", value)
+		}
+	}
+	return nil
+}
+
+// encodable reports whether value can be marshaled as JSON. Common scalar
+// types are accepted without attempting to encode them.
+func encodable(value any) bool {
+	switch v := value.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, time.Time, time.Duration:
+		return true
+	case float64:
+		return !isNaNOrInf(v)
+	case float32:
+		return !isNaNOrInf(float64(v))
+	default:
+		_, err := json.Marshal(value)
+		return err == nil
+	}
+}
+
+// isNaNOrInf reports whether f has no JSON representation
+func isNaNOrInf(f float64) bool {
+	return f != f || f > 1.7976931348623157e308 || f < -1.7976931348623157e308
+}
+
+// fieldMatcher matches attribute names against exact names and glob
+// patterns such as "internal.*"
+type fieldMatcher struct {
+	names    map[string]bool
+	patterns []string
+}
+
+// newFieldMatcher compiles names; it returns nil when names is empty
+func newFieldMatcher(names []string) *fieldMatcher {
+	if len(names) == 0 {
+		return nil
+	}
+
+	m := &fieldMatcher{names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		if strings.ContainsAny(name, "*?[") {
+			m.patterns = append(m.patterns, name)
+		} else {
+			m.names[name] = true
+		}
+	}
+	return m
+}
+
+func (m *fieldMatcher) match(key string) bool {
+	if m.names[key] {
+		return true
+	}
+	for _, pattern := range m.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeFields removes attributes matching Config.ExcludeFields
+func (w *Writer) excludeFields(fields map[string]any) {
+	for key := range fields {
+		if w.exclude.match(key) {
+			delete(fields, key)
+		}
+	}
+}
+
+// Kinds of iris fields without an exported predicate
+var (
+	secretKind   = iris.Secret("", "").Type()
+	errorKind    = iris.NamedError("", nil).Type()
+	stringerKind = iris.Stringer("", nil).Type()
+	objectKind   = iris.Object("", nil).Type()
+)
+
+// recordFields copies the record's structured fields into fields as
+// attributes, overriding DefaultFields of the same name. Fields the
+// writer consumes itself (the timestamp override and the continuation
+// flag) are skipped.
+func recordFields(record *iris.Record, fields map[string]any) {
+	for i := 0; i < record.FieldCount(); i++ {
+		field := record.GetField(i)
+		if field.K == "" || field.K == timestampKey || field.K == continuationKey {
+			continue
+		}
+		fields[field.K] = fieldValue(field)
+	}
+}
+
+// fieldValue converts an iris field to the value encoded for Datadog:
+// numbers and booleans stay JSON numbers and booleans, durations are
+// nanoseconds, times are RFC 3339 strings and secrets are redacted. Bytes
+// are a base64 string, as encoding/json sends []byte, which is compact
+// and copies them out of the record.
+func fieldValue(field iris.Field) any {
+	switch {
+	case field.IsString():
+		return field.Str
+	case field.IsInt():
+		return field.I64
+	case field.IsUint():
+		return field.U64
+	case field.IsFloat():
+		return field.F64
+	case field.IsBool():
+		return field.BoolValue()
+	case field.IsDuration():
+		return field.I64
+	case field.IsTime():
+		return field.TimeValue().UTC().Format(time.RFC3339Nano)
+	case field.IsBytes():
+		return base64.StdEncoding.EncodeToString(field.B)
+	}
+
+	switch field.Type() {
+	case secretKind:
+		return "[REDACTED]"
+	case errorKind:
+		if err, ok := field.Obj.(error); ok {
+			return err.Error()
+		}
+		return nil
+	case stringerKind:
+		if stringer, ok := field.Obj.(fmt.Stringer); ok && stringer != nil {
+			return stringer.String()
+		}
+		return nil
+	case objectKind:
+		return field.Obj
+	}
+	return nil
+}
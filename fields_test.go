@@ -0,0 +1,201 @@
+// fields_test.go: Custom attribute handling tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestSanitizeFields(t *testing.T) {
+	newFields := func() map[string]any {
+		return map[string]any{
+			"ok":      "value",
+			"count":   3,
+			"channel": make(chan int),
+			"nan":     math.NaN(),
+		}
+	}
+
+	t.Run("stringify", func(t *testing.T) {
+		writer := &Writer{config: Config{UnsupportedFieldPolicy: FieldPolicyStringify}}
+		fields := newFields()
+
+		if err := writer.sanitizeFields(fields); err != nil {
+			t.Fatalf("sanitizeFields() error = %v", err)
+		}
+		if s, ok := fields["channel"].(string); !ok || !strings.HasPrefix(s, "0x") {
+			t.Errorf("channel = %v, want stringified pointer", fields["channel"])
+		}
+		if fields["nan"] != "NaN" {
+			t.Errorf("nan = %v, want %q", fields["nan"], "NaN")
+		}
+		if _, err := json.Marshal(LogEntry{Fields: fields}); err != nil {
+			t.Errorf("Sanitized entry failed to marshal: %v", err)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		writer := &Writer{config: Config{UnsupportedFieldPolicy: FieldPolicyDrop}}
+		fields := newFields()
+
+		if err := writer.sanitizeFields(fields); err != nil {
+			t.Fatalf("sanitizeFields() error = %v", err)
+		}
+		if _, ok := fields["channel"]; ok {
+			t.Error("Expected channel field to be dropped")
+		}
+		if fields["ok"] != "value" || fields["count"] != 3 {
+			t.Errorf("Supported fields were modified: %v", fields)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		writer := &Writer{config: Config{UnsupportedFieldPolicy: FieldPolicyError}}
+		fields := map[string]any{"channel": make(chan int)}
+
+		if err := writer.sanitizeFields(fields); err == nil || !strings.Contains(err.Error(), "channel") {
+			t.Errorf("sanitizeFields() error = %v, want unsupported type error", err)
+		}
+	})
+}
+
+func TestWriter_ExcludeFields(t *testing.T) {
+	writer, err := New(Config{
+		APIKey: "test-api-key",
+		DefaultFields: map[string]any{
+			"region":         "eu-west-1",
+			"internal.trace": "frame dump",
+			"internal.state": 42,
+			"blob":           strings.Repeat("x", 1024),
+		},
+		ExcludeFields: []string{"internal.*", "blob"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	payload, err := json.Marshal(writer.buildLogEntry(iris.NewRecord(iris.Info, "message")))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, excluded := range []string{"internal.trace", "internal.state", "blob"} {
+		if strings.Contains(string(payload), `"`+excluded+`"`) {
+			t.Errorf("payload contains excluded field %q: %s", excluded, payload)
+		}
+	}
+	if !strings.Contains(string(payload), `"region":"eu-west-1"`) {
+		t.Errorf("payload lost a kept field: %s", payload)
+	}
+}
+
+func TestFieldMatcher(t *testing.T) {
+	m := newFieldMatcher([]string{"secret", "debug_?", "tmp.*"})
+	tests := map[string]bool{
+		"secret":     true,
+		"secrets":    false,
+		"debug_1":    true,
+		"debug_10":   false,
+		"tmp.buffer": true,
+		"tmp":        false,
+	}
+	for key, want := range tests {
+		if got := m.match(key); got != want {
+			t.Errorf("match(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if newFieldMatcher(nil) != nil {
+		t.Error("newFieldMatcher(nil) should return nil")
+	}
+}
+
+func TestWriter_ArrayFieldsStayArrays(t *testing.T) {
+	var out strings.Builder
+	writer, err := New(Config{
+		Output:       OutputStdout,
+		OutputWriter: &out,
+		DefaultFields: map[string]any{
+			"regions": []string{"eu", "us"},
+			"ids":     []int{1, 2, 3},
+			"mixed":   []any{"a", 1, true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "lists"))
+	_ = writer.Close()
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &entry); err != nil {
+		t.Fatalf("invalid output %q: %v", out.String(), err)
+	}
+	for key, want := range map[string]int{"regions": 2, "ids": 3, "mixed": 3} {
+		got, ok := entry[key].([]any)
+		if !ok || len(got) != want {
+			t.Errorf("%s = %#v, want a JSON array of %d elements", key, entry[key], want)
+		}
+	}
+}
+
+func TestWriter_RecordFieldsBecomeAttributes(t *testing.T) {
+	var out strings.Builder
+	writer, err := New(Config{
+		Output:        OutputStdout,
+		OutputWriter:  &out,
+		DefaultFields: map[string]any{"region": "eu-west-1", "user_id": "default"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	at := time.Date(2025, 9, 6, 10, 15, 30, 0, time.UTC)
+	record := iris.NewRecord(iris.Info, "user signed in")
+	record.AddField(iris.Int("user_id", 12345))
+	record.AddField(iris.Float64("score", 0.75))
+	record.AddField(iris.Bool("admin", true))
+	record.AddField(iris.Str("plan", "pro"))
+	record.AddField(iris.Time("signed_in_at", at))
+	record.AddField(iris.Dur("duration", 1500*time.Millisecond))
+	record.AddField(iris.Secret("password", "hunter2"))
+	record.AddField(iris.NamedError("cause", errors.New("token expired")))
+	record.AddField(iris.Bytes("digest", []byte{0xde, 0xad, 0xbe, 0xef}))
+	_ = writer.WriteRecord(record)
+	_ = writer.Close()
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(out.String()), &entry); err != nil {
+		t.Fatalf("invalid output %q: %v", out.String(), err)
+	}
+	want := map[string]any{
+		"user_id":      float64(12345),
+		"score":        0.75,
+		"admin":        true,
+		"plan":         "pro",
+		"signed_in_at": "2025-09-06T10:15:30Z",
+		"duration":     float64(1500 * time.Millisecond),
+		"password":     "[REDACTED]",
+		"cause":        "token expired",
+		"digest":       "3q2+7w==",
+		"region":       "eu-west-1",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %#v, want %#v", key, entry[key], value)
+		}
+	}
+	if !strings.Contains(out.String(), `"user_id":12345`) {
+		t.Errorf("user_id is not a JSON number: %s", out.String())
+	}
+}
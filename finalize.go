@@ -0,0 +1,74 @@
+// finalize.go: Safety net for writers that are never closed
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"time"
+	"weak"
+)
+
+// ErrNotClosed is reported to Config.OnError when a writer is garbage
+// collected without Close having been called. Its buffered entries are
+// lost; the safety net only stops the flush timer.
+var ErrNotClosed = errors.New("datadog writer was garbage collected without Close: buffered logs were lost")
+
+// abandonGuard owns the flush timer outside the Writer, so a cleanup can
+// stop it once the Writer is unreachable. It must not reference the
+// Writer, or the Writer would never become unreachable.
+type abandonGuard struct {
+	timer   atomic.Pointer[time.Timer]
+	onError func(error)
+}
+
+// guardAbandoned arranges for the flush timer to be stopped, and
+// ErrNotClosed reported, if the writer is collected without Close.
+// runtime.AddCleanup is used rather than SetFinalizer because the Writer
+// references itself (e.g. through its HTTP trace hooks), and finalizers
+// never run on objects in cycles. Relying on this is no substitute for
+// Close: collection may happen late or not at all, and the buffer is not
+// flushed.
+func (w *Writer) guardAbandoned() {
+	w.guard = &abandonGuard{onError: w.config.OnError}
+	w.cleanup = runtime.AddCleanup(w, (*abandonGuard).abandoned, w.guard)
+}
+
+// abandoned runs after the writer was collected without Close
+func (g *abandonGuard) abandoned() {
+	if timer := g.timer.Swap(nil); timer != nil {
+		timer.Stop()
+	}
+	if g.onError != nil {
+		g.onError(ErrNotClosed)
+	}
+}
+
+// stopFlushTimer stops the flush timer and, as Close was called, disarms
+// the cleanup
+func (w *Writer) stopFlushTimer() {
+	if w.guard == nil {
+		return
+	}
+	if timer := w.guard.timer.Swap(nil); timer != nil {
+		timer.Stop()
+	}
+	w.cleanup.Stop()
+}
+
+// armFlushTimer schedules the next timed flush. The callback holds only a
+// weak reference, so a pending timer does not keep an abandoned writer
+// alive.
+func armFlushTimer(ref weak.Pointer[Writer], delay time.Duration) *time.Timer {
+	return time.AfterFunc(delay, func() {
+		if w := ref.Value(); w != nil {
+			_ = w.flushIf(w.timedFlushDue)
+			w.startFlushTimer()
+		}
+	})
+}
@@ -0,0 +1,64 @@
+// finalize_test.go: Unclosed writer safety net tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_CollectedWithoutClose(t *testing.T) {
+	errs := make(chan error, 1)
+	func() {
+		writer, err := New(Config{
+			CaptureMode:   true,
+			FlushInterval: 20 * time.Millisecond,
+			OnError:       func(err error) { errs <- err },
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, "forgotten"))
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrNotClosed) {
+				t.Fatalf("OnError(%v), want ErrNotClosed", err)
+			}
+			return
+		case <-deadline:
+			t.Fatal("abandoned writer was never collected; its flush timer keeps it alive")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestWriter_CloseDisarmsSafetyNet(t *testing.T) {
+	errs := make(chan error, 1)
+	writer, err := New(Config{CaptureMode: true, OnError: func(err error) { errs <- err }})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.Close()
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("OnError(%v) after Close", err)
+	default:
+	}
+}
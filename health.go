@@ -0,0 +1,128 @@
+// health.go: Failure threshold and degraded mode for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrWriterDisabled is reported through OnError when the writer disables
+// itself after Config.DisableAfterConsecutiveFailures failed batches
+var ErrWriterDisabled = errors.New("datadog writer disabled after consecutive failures")
+
+// Healthy reports whether the writer is accepting and shipping logs
+func (w *Writer) Healthy() bool {
+	return !w.disabled.Load()
+}
+
+// Resume re-enables a writer that disabled itself after repeated failures
+func (w *Writer) Resume() {
+	w.timerMutex.Lock()
+	if w.probeTimer != nil {
+		w.probeTimer.Stop()
+		w.probeTimer = nil
+	}
+	w.timerMutex.Unlock()
+
+	w.stats.consecutiveFailures.Store(0)
+	w.disabled.Store(false)
+}
+
+// recordSuccess updates counters after a batch was accepted
+func (w *Writer) recordSuccess(entries int) {
+	w.stats.sent.Add(uint64(entries))
+	w.root().stats.consecutiveFailures.Store(0)
+}
+
+// recordFailure updates counters after a batch was lost and disables the
+// writer once the configured failure threshold is reached. Failures of
+// partitions count towards, and disable, their parent.
+func (w *Writer) recordFailure(entries int) {
+	w.stats.dropped.Add(uint64(entries))
+	root := w.root()
+	failures := root.stats.consecutiveFailures.Add(1)
+
+	threshold := w.config.DisableAfterConsecutiveFailures
+	if threshold > 0 && failures >= uint64(threshold) {
+		root.disable()
+	}
+}
+
+// disable stops buffering, releases buffered entries, including those of
+// every partition, and starts probing
+func (w *Writer) disable() {
+	if !w.disabled.CompareAndSwap(false, true) {
+		return
+	}
+
+	w.discardBuffer()
+	for _, partition := range w.partitionList() {
+		partition.discardBuffer()
+	}
+
+	w.handleError(fmt.Errorf("%w: %d failed batches", ErrWriterDisabled, w.stats.consecutiveFailures.Load()))
+	w.scheduleProbe()
+}
+
+// discardBuffer releases the buffered entries, counting them as dropped
+func (w *Writer) discardBuffer() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.stats.dropped.Add(uint64(len(w.buffer)))
+	w.wal.release(w.buffer)
+	w.buffer = w.newBuffer()
+	w.resetBufferBytes()
+}
+
+// scheduleProbe arms the timer that checks whether Datadog is reachable again
+func (w *Writer) scheduleProbe() {
+	w.timerMutex.Lock()
+	defer w.timerMutex.Unlock()
+
+	if w.closed.Load() || w.probeTimer != nil {
+		return
+	}
+
+	w.probeTimer = time.AfterFunc(w.config.ProbeInterval, func() {
+		w.timerMutex.Lock()
+		w.probeTimer = nil
+		w.timerMutex.Unlock()
+
+		if !w.disabled.Load() {
+			return
+		}
+		if w.probe() == nil {
+			w.Resume()
+			return
+		}
+		w.scheduleProbe()
+	})
+}
+
+// probe validates the API key against the Datadog API
+func (w *Writer) probe() error {
+	req, err := http.NewRequest("GET", w.apiURL("/api/v1/validate"), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("DD-API-KEY", w.config.APIKey)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("datadog probe failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// health_test.go: Failure threshold and degraded mode tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_DisableAfterConsecutiveFailures(t *testing.T) {
+	var up atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if up.Load() {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var disabledReported atomic.Bool
+	writer, err := New(Config{
+		APIKey:                          "test-api-key",
+		Site:                            strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:                       1,
+		FlushInterval:                   time.Hour,
+		MaxRetries:                      1,
+		RetryDelay:                      time.Millisecond,
+		DisableAfterConsecutiveFailures: 2,
+		ProbeInterval:                   20 * time.Millisecond,
+		OnError: func(err error) {
+			if errors.Is(err, ErrWriterDisabled) {
+				disabledReported.Store(true)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	record := iris.NewRecord(iris.Info, "message")
+
+	_ = writer.WriteRecord(record)
+	if !writer.Healthy() {
+		t.Fatal("Writer disabled before reaching the threshold")
+	}
+	_ = writer.WriteRecord(record)
+	if writer.Healthy() {
+		t.Fatal("Expected writer to be disabled after 2 consecutive failures")
+	}
+	if !disabledReported.Load() {
+		t.Error("Expected ErrWriterDisabled to be reported via OnError")
+	}
+
+	// Writes are dropped immediately while disabled
+	before := writer.Stats().EntriesDropped
+	if err := writer.WriteRecord(record); err != nil {
+		t.Errorf("WriteRecord() error = %v", err)
+	}
+	if got := writer.Stats().EntriesDropped; got != before+1 {
+		t.Errorf("EntriesDropped = %d, want %d", got, before+1)
+	}
+
+	writer.Resume()
+	if !writer.Healthy() || writer.Stats().ConsecutiveFailures != 0 {
+		t.Fatal("Expected Resume to re-enable the writer")
+	}
+
+	// Disable again, then let a successful probe re-enable it
+	_ = writer.WriteRecord(record)
+	_ = writer.WriteRecord(record)
+	if writer.Healthy() {
+		t.Fatal("Expected writer to be disabled again")
+	}
+
+	up.Store(true)
+	deadline := time.Now().Add(2 * time.Second)
+	for !writer.Healthy() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !writer.Healthy() {
+		t.Fatal("Expected a successful probe to re-enable the writer")
+	}
+
+	if err := writer.WriteRecord(record); err != nil {
+		t.Errorf("WriteRecord() after recovery error = %v", err)
+	}
+	if got := writer.Stats().EntriesSent; got != 1 {
+		t.Errorf("EntriesSent = %d, want 1", got)
+	}
+}
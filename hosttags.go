@@ -0,0 +1,63 @@
+// hosttags.go: Tags derived from the hostname for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// applyHostnameTags adds a tag for every named capture group of
+// Config.HostnameTagPattern that matches the hostname (Config.Hostname,
+// or the OS hostname when unset). Explicitly configured tags win.
+func applyHostnameTags(config *Config) error {
+	if config.HostnameTagPattern == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(config.HostnameTagPattern)
+	if err != nil {
+		return fmt.Errorf("invalid HostnameTagPattern: %w", err)
+	}
+
+	hostname := config.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	derived := hostnameTags(pattern, hostname)
+	if len(derived) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string, len(config.Tags)+len(derived))
+	for key, value := range derived {
+		tags[key] = value
+	}
+	for key, value := range config.Tags {
+		tags[key] = value
+	}
+	config.Tags = tags
+	return nil
+}
+
+// hostnameTags returns the non-empty named captures of pattern in hostname
+func hostnameTags(pattern *regexp.Regexp, hostname string) map[string]string {
+	match := pattern.FindStringSubmatch(hostname)
+	if match == nil {
+		return nil
+	}
+
+	tags := make(map[string]string)
+	for i, name := range pattern.SubexpNames() {
+		if name != "" && match[i] != "" {
+			tags[name] = match[i]
+		}
+	}
+	return tags
+}
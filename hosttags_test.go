@@ -0,0 +1,51 @@
+// hosttags_test.go: Hostname-derived tag tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+)
+
+func TestNew_HostnameTagPattern(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:             "test-api-key",
+		Hostname:           "us-east-1a-web-03",
+		HostnameTagPattern: `^(?P<region>[a-z]+-[a-z]+-\d)(?P<az>[a-z])-(?P<role>[a-z]+)-\d+$`,
+		Tags:               map[string]string{"role": "frontend"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	want := "az:a,region:us-east-1,role:frontend"
+	if got := writer.currentTags().ddtags; got != want {
+		t.Errorf("ddtags = %q, want %q", got, want)
+	}
+}
+
+func TestNew_HostnameTagPatternNoMatch(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:             "test-api-key",
+		Hostname:           "laptop",
+		HostnameTagPattern: `^(?P<region>[a-z]+-[a-z]+-\d)-`,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if got := writer.currentTags().ddtags; got != "" {
+		t.Errorf("ddtags = %q, want no derived tags", got)
+	}
+}
+
+func TestNew_InvalidHostnameTagPattern(t *testing.T) {
+	if _, err := New(Config{APIKey: "test-api-key", HostnameTagPattern: `(?P<region>`}); err == nil {
+		t.Error("Expected error for invalid HostnameTagPattern")
+	}
+}
@@ -0,0 +1,73 @@
+// lag.go: Delivery lag between log generation and delivery
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"time"
+)
+
+// oldestTimestamp returns the earliest timestamp of entries, 0 when empty
+func oldestTimestamp(entries []LogEntry) int64 {
+	var oldest int64
+	for _, entry := range entries {
+		if oldest == 0 || entry.Timestamp < oldest {
+			oldest = entry.Timestamp
+		}
+	}
+	return oldest
+}
+
+// noteBuffered lowers the oldest buffered timestamp to ts. Must be called
+// with mutex held.
+func (w *Writer) noteBuffered(ts int64) {
+	if w.oldestBuffered == 0 || ts < w.oldestBuffered {
+		w.oldestBuffered = ts
+	}
+}
+
+// trackInFlight registers a batch being delivered and returns the token
+// to release it with
+func (w *Writer) trackInFlight(entries []LogEntry) uint64 {
+	oldest := oldestTimestamp(entries)
+	w.inFlightMutex.Lock()
+	defer w.inFlightMutex.Unlock()
+	if w.inFlightOldest == nil {
+		w.inFlightOldest = make(map[uint64]int64)
+	}
+	w.inFlightSeq++
+	w.inFlightOldest[w.inFlightSeq] = oldest
+	return w.inFlightSeq
+}
+
+// releaseInFlight forgets a batch once its delivery finished, delivered,
+// dropped or requeued
+func (w *Writer) releaseInFlight(token uint64) {
+	w.inFlightMutex.Lock()
+	delete(w.inFlightOldest, token)
+	w.inFlightMutex.Unlock()
+}
+
+// deliveryLag returns how long ago the oldest buffered or in-flight entry
+// was generated, zero when nothing is pending
+func (w *Writer) deliveryLag(now time.Time) time.Duration {
+	w.mutex.Lock()
+	oldest := w.oldestBuffered
+	w.mutex.Unlock()
+
+	w.inFlightMutex.Lock()
+	for _, ts := range w.inFlightOldest {
+		if oldest == 0 || ts < oldest {
+			oldest = ts
+		}
+	}
+	w.inFlightMutex.Unlock()
+
+	if oldest == 0 {
+		return 0
+	}
+	return max(now.Sub(time.UnixMilli(oldest)), 0)
+}
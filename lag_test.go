@@ -0,0 +1,62 @@
+// lag_test.go: Delivery lag tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_DeliveryLag(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if lag := writer.Stats().DeliveryLag; lag != 0 {
+		t.Errorf("empty writer lag = %v, want 0", lag)
+	}
+
+	_ = writer.WriteRecordAt(time.Now().Add(-2*time.Second), iris.NewRecord(iris.Info, "old"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "new"))
+	if lag := writer.Stats().DeliveryLag; lag < 2*time.Second || lag > 3*time.Second {
+		t.Errorf("buffered lag = %v, want about 2s from the oldest entry", lag)
+	}
+
+	done := make(chan error)
+	go func() { done <- writer.Flush() }()
+	<-arrived
+	if lag := writer.Stats().DeliveryLag; lag < 2*time.Second {
+		t.Errorf("in-flight lag = %v, want the in-flight batch counted", lag)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if lag := writer.Stats().DeliveryLag; lag != 0 {
+		t.Errorf("lag after delivery = %v, want 0", lag)
+	}
+}
@@ -0,0 +1,153 @@
+// limits.go: Size limits for entries sent to the Datadog intake
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"reflect"
+	"unicode/utf8"
+)
+
+const (
+	// defaultLargeEntryBytes is the message size above which an entry is
+	// isolated in its own request
+	defaultLargeEntryBytes = 256 * 1024
+
+	// defaultMaxMessageBytes matches the Datadog per-log size limit
+	defaultMaxMessageBytes = 1000 * 1000
+
+	// truncationMarker is appended to values cut to fit a size limit
+	truncationMarker = "...[truncated]"
+
+	// truncatedFieldsKey maps each field cut by MaxFieldValueBytes to its
+	// original length in bytes
+	truncatedFieldsKey = "truncated_fields"
+)
+
+// partitionLarge splits entries into those that can share a request and
+// those whose message exceeds Config.LargeEntryBytes. The common case of
+// no large entries returns the input slice without copying.
+func (w *Writer) partitionLarge(entries []LogEntry) (regular, large []LogEntry) {
+	limit := w.config.LargeEntryBytes
+	if limit <= 0 {
+		return entries, nil
+	}
+
+	for i, entry := range entries {
+		if len(entry.Message) <= limit {
+			continue
+		}
+		if large == nil {
+			regular = append(make([]LogEntry, 0, len(entries)), entries[:i]...)
+		}
+		large = append(large, entry)
+	}
+	if large == nil {
+		return entries, nil
+	}
+
+	for _, entry := range entries[len(regular):] {
+		if len(entry.Message) <= limit {
+			regular = append(regular, entry)
+		}
+	}
+	return regular, large
+}
+
+// truncatesMessage reports whether a message of n bytes is truncated:
+// only entries isolated for exceeding Config.LargeEntryBytes are cut to
+// Config.MaxMessageBytes. StrictMode rejects exactly these messages.
+func (w *Writer) truncatesMessage(n int) bool {
+	large, limit := w.config.LargeEntryBytes, w.config.MaxMessageBytes
+	return large > 0 && n > large && limit > 0 && n > limit
+}
+
+// truncateEntry cuts the message of an entry to Config.MaxMessageBytes
+func (w *Writer) truncateEntry(entry LogEntry) LogEntry {
+	limit := w.config.MaxMessageBytes
+	if limit <= 0 || len(entry.Message) <= limit {
+		return entry
+	}
+	entry.Message = truncateUTF8(entry.Message, limit)
+	w.stats.truncated.Add(1)
+	return entry
+}
+
+// truncateFieldValues cuts string field values and array fields to
+// Config.MaxFieldValueBytes and records the original lengths in bytes
+// under truncatedFieldsKey. Arrays keep their leading elements.
+func (w *Writer) truncateFieldValues(fields map[string]any) {
+	limit := w.config.MaxFieldValueBytes
+	var original map[string]int
+	for key, value := range fields {
+		var size int
+		if s, ok := value.(string); ok {
+			if len(s) <= limit {
+				continue
+			}
+			size = len(s)
+			fields[key] = truncateUTF8(s, limit)
+		} else if trimmed, encoded, ok := trimSlice(value, limit); ok {
+			size = encoded
+			fields[key] = trimmed
+		} else {
+			continue
+		}
+		if original == nil {
+			original = make(map[string]int)
+		}
+		original[key] = size
+	}
+	if original != nil {
+		fields[truncatedFieldsKey] = original
+		w.stats.fieldsTruncated.Add(uint64(len(original)))
+	}
+}
+
+// trimSlice drops trailing elements of a slice value until its JSON
+// encoding fits in limit bytes. It reports the original encoded size and
+// false for non-slices, []byte and slices that already fit.
+func trimSlice(value any, limit int) (any, int, bool) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, 0, false
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil || len(encoded) <= limit {
+		return nil, 0, false
+	}
+
+	// Binary search for the longest prefix that fits
+	lo, hi := 0, rv.Len()
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		prefix, err := json.Marshal(rv.Slice(0, mid).Interface())
+		if err == nil && len(prefix) <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return rv.Slice(0, lo).Interface(), len(encoded), true
+}
+
+// truncateUTF8 shortens s to at most limit bytes including the truncation
+// marker, without splitting a multi-byte UTF-8 sequence
+func truncateUTF8(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+
+	cut := limit - len(truncationMarker)
+	if cut <= 0 {
+		return truncationMarker[:limit]
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncationMarker
+}
@@ -0,0 +1,158 @@
+// limits_test.go: Entry size limit tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/agilira/iris"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("short", 10); got != "short" {
+		t.Errorf("truncateUTF8() = %q, want unchanged", got)
+	}
+
+	// Each 'é' is two bytes; the cut must not land inside one
+	s := strings.Repeat("é", 20)
+	got := truncateUTF8(s, len(truncationMarker)+5)
+	if !utf8.ValidString(got) {
+		t.Errorf("truncateUTF8() produced invalid UTF-8: %q", got)
+	}
+	if len(got) > len(truncationMarker)+5 || !strings.HasSuffix(got, truncationMarker) {
+		t.Errorf("truncateUTF8() = %q", got)
+	}
+}
+
+func TestWriter_LargeEntryIsolation(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var batch []map[string]any
+		_ = json.Unmarshal(body, &batch)
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:          "test-api-key",
+		Site:            strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:       3,
+		FlushInterval:   time.Hour,
+		LargeEntryBytes: 64,
+		MaxMessageBytes: 128,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "healthy one"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, strings.Repeat("x", 500)))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "healthy two"))
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(batches) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(batches))
+	}
+	if len(batches[0]) != 2 || batches[0][0]["message"] != "healthy one" || batches[0][1]["message"] != "healthy two" {
+		t.Errorf("Regular batch = %v", batches[0])
+	}
+
+	if len(batches[1]) != 1 {
+		t.Fatalf("Expected the large entry alone, got %d entries", len(batches[1]))
+	}
+	message, _ := batches[1][0]["message"].(string)
+	if len(message) != 128 || !strings.HasSuffix(message, truncationMarker) {
+		t.Errorf("Large message length %d, want truncated to 128", len(message))
+	}
+	if got := writer.Stats().EntriesTruncated; got != 1 {
+		t.Errorf("EntriesTruncated = %d, want 1", got)
+	}
+}
+
+func TestNew_LimitDefaults(t *testing.T) {
+	writer, err := New(Config{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if writer.config.LargeEntryBytes != defaultLargeEntryBytes || writer.config.MaxMessageBytes != defaultMaxMessageBytes {
+		t.Errorf("LargeEntryBytes/MaxMessageBytes = %d/%d, want documented defaults",
+			writer.config.LargeEntryBytes, writer.config.MaxMessageBytes)
+	}
+}
+
+func TestWriter_MaxFieldValueBytes(t *testing.T) {
+	blob := strings.Repeat("é", 100) // 200 bytes
+	writer := &Writer{config: Config{
+		MaxFieldValueBytes: 64,
+		DefaultFields:      map[string]any{"blob": blob, "small": "ok", "count": 7},
+	}}
+
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Info, "upload"))
+
+	got, _ := entry.Fields["blob"].(string)
+	if len(got) > 64 || !utf8.ValidString(got) || !strings.HasSuffix(got, truncationMarker) {
+		t.Errorf("blob = %q (%d bytes), want valid UTF-8 of at most 64 bytes with marker", got, len(got))
+	}
+	if entry.Fields["small"] != "ok" || entry.Fields["count"] != 7 {
+		t.Errorf("short and non-string fields changed: %v", entry.Fields)
+	}
+	original, _ := entry.Fields[truncatedFieldsKey].(map[string]int)
+	if original["blob"] != len(blob) || len(original) != 1 {
+		t.Errorf("%s = %v, want blob: %d", truncatedFieldsKey, original, len(blob))
+	}
+	if writer.Stats().FieldsTruncated != 1 {
+		t.Errorf("FieldsTruncated = %d, want 1", writer.Stats().FieldsTruncated)
+	}
+}
+
+func TestWriter_MaxFieldValueBytesArrays(t *testing.T) {
+	ids := make([]int, 100)
+	for i := range ids {
+		ids[i] = 1000 + i
+	}
+	writer := &Writer{config: Config{
+		MaxFieldValueBytes: 32,
+		DefaultFields:      map[string]any{"ids": ids, "few": []string{"a", "b"}},
+	}}
+
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Info, "batch"))
+
+	trimmed, ok := entry.Fields["ids"].([]int)
+	if !ok {
+		t.Fatalf("ids = %T, want []int", entry.Fields["ids"])
+	}
+	encoded, _ := json.Marshal(trimmed)
+	if len(encoded) > 32 || len(trimmed) == 0 || trimmed[0] != 1000 {
+		t.Errorf("ids = %s, want a leading prefix within 32 bytes", encoded)
+	}
+	if few, _ := entry.Fields["few"].([]string); len(few) != 2 {
+		t.Errorf("few = %v, want unchanged", entry.Fields["few"])
+	}
+	full, _ := json.Marshal(ids)
+	original, _ := entry.Fields[truncatedFieldsKey].(map[string]int)
+	if original["ids"] != len(full) || len(original) != 1 {
+		t.Errorf("%s = %v, want ids: %d", truncatedFieldsKey, original, len(full))
+	}
+}
@@ -0,0 +1,50 @@
+// logid.go: Unique per-entry IDs for deduplication
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// logIDKey is the attribute holding the entry's unique ID
+const logIDKey = "dd.log_id"
+
+// logIDSource generates IDs from a random per-writer node ID and an
+// atomic sequence: unique across processes without coordination, and a
+// single atomic add per entry on the hot path
+type logIDSource struct {
+	node [8]byte
+}
+
+// newLogIDSource returns a source with a fresh random node ID
+func newLogIDSource() *logIDSource {
+	source := &logIDSource{}
+	_, _ = rand.Read(source.node[:])
+	return source
+}
+
+// next formats node and seq as 32 hex characters; IDs from one writer
+// sort in generation order
+func (s *logIDSource) next(seq uint64) string {
+	var raw [16]byte
+	copy(raw[:8], s.node[:])
+	binary.BigEndian.PutUint64(raw[8:], seq)
+	return hex.EncodeToString(raw[:])
+}
+
+// logID returns the ID for a new entry, from Config.LogIDGenerator when set
+func (w *Writer) logID() string {
+	if w.config.LogIDGenerator != nil {
+		return w.config.LogIDGenerator()
+	}
+	if w.logIDs == nil {
+		return newUUID()
+	}
+	return w.logIDs.next(w.logIDSeq.Add(1))
+}
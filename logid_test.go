@@ -0,0 +1,81 @@
+// logid_test.go: Per-entry log ID tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_EmitLogIDUnique(t *testing.T) {
+	const goroutines, perGoroutine = 8, 500
+
+	writer, err := New(Config{CaptureMode: true, EmitLogID: true, BatchSize: 100})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				_ = writer.WriteRecord(iris.NewRecord(iris.Info, "identified"))
+			}
+		}()
+	}
+	wg.Wait()
+	_ = writer.Close()
+
+	seen := make(map[string]bool)
+	for _, batch := range writer.CapturedBatches() {
+		for _, entry := range batch.Entries {
+			id, _ := entry.Fields[logIDKey].(string)
+			if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+				t.Fatalf("dd.log_id = %q, want 32 hex characters", id)
+			}
+			if seen[id] {
+				t.Fatalf("duplicate dd.log_id %q", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("saw %d IDs, want %d", len(seen), goroutines*perGoroutine)
+	}
+}
+
+func TestWriter_LogIDGenerator(t *testing.T) {
+	writer := &Writer{config: Config{EmitLogID: true, LogIDGenerator: func() string { return "ticket-42" }}}
+	if got := writer.buildLogEntry(iris.NewRecord(iris.Info, "custom")).Fields[logIDKey]; got != "ticket-42" {
+		t.Errorf("dd.log_id = %v, want the generator's ID", got)
+	}
+
+	other, err := New(Config{CaptureMode: true, EmitLogID: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = other.Close() }()
+	a := other.buildLogEntry(iris.NewRecord(iris.Info, "a")).Fields[logIDKey].(string)
+	b := other.buildLogEntry(iris.NewRecord(iris.Info, "b")).Fields[logIDKey].(string)
+	if a[:16] != b[:16] || a >= b {
+		t.Errorf("IDs %q, %q: want a shared node prefix and increasing sequence", a, b)
+	}
+}
+
+func BenchmarkLogID(b *testing.B) {
+	writer := &Writer{config: Config{EmitLogID: true}, logIDs: newLogIDSource()}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = writer.logID()
+		}
+	})
+}
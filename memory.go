@@ -0,0 +1,219 @@
+// memory.go: Buffer memory bound for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// BufferPolicy selects which entries are dropped once the buffer reaches
+// Config.MaxBufferBytes
+type BufferPolicy int
+
+const (
+	// BufferDropOldest evicts the oldest buffered entries to make room
+	BufferDropOldest BufferPolicy = iota
+
+	// BufferDropNewest discards the incoming entry
+	BufferDropNewest
+
+	// BufferRejectNew discards the incoming entry and returns ErrBufferFull
+	// from WriteRecord
+	BufferRejectNew
+)
+
+// ErrBufferFull is returned by WriteRecord with BufferRejectNew when the
+// buffer is at Config.MaxBufferBytes
+var ErrBufferFull = errors.New("datadog writer buffer is full")
+
+// entryOverhead covers the encoded size of an entry's fixed keys,
+// timestamp and punctuation when every fixed attribute is present
+const entryOverhead = 128
+
+// entrySize estimates the encoded size of entry without marshaling it.
+// The estimate leans high, so byte-based limits trigger slightly early
+// rather than late; only heavy JSON escaping can push the encoded size
+// above it.
+func entrySize(entry LogEntry) int {
+	size := entryOverhead + len(entry.Level) + len(entry.Message) + len(entry.Service) + len(entry.Source) +
+		len(entry.Tags) + len(entry.Hostname) + len(entry.Env) + len(entry.Version)
+	for key, value := range entry.Fields {
+		size += len(key) + 4 + valueSize(value)
+	}
+	return size
+}
+
+// maxSizeDepth bounds how deep valueSize descends into nested values
+const maxSizeDepth = 8
+
+// numberSize is the estimate for a float, long enough for any float64
+const numberSize = 24
+
+// valueSize estimates the encoded size of an attribute value. The common
+// attribute types take a fast path; other slices, arrays, maps and
+// structs are sized element by element through reflection, so a large
+// array counts for what it encodes to.
+func valueSize(value any) int {
+	switch v := value.(type) {
+	case string:
+		return len(v) + 2
+	case bool, nil:
+		return 5
+	case int:
+		return intSize(int64(v))
+	case int64:
+		return intSize(v)
+	case float64:
+		return numberSize
+	case []byte:
+		return base64.StdEncoding.EncodedLen(len(v)) + 2
+	case []string:
+		size := 2
+		for _, s := range v {
+			size += len(s) + 3
+		}
+		return size
+	case []any:
+		size := 2
+		for _, item := range v {
+			size += valueSize(item) + 1
+		}
+		return size
+	case map[string]any:
+		size := 2
+		for key, item := range v {
+			size += len(key) + 4 + valueSize(item)
+		}
+		return size
+	default:
+		return reflectSize(reflect.ValueOf(value), 0)
+	}
+}
+
+// reflectSize estimates the encoded size of v for the types valueSize
+// has no fast path for
+func reflectSize(v reflect.Value, depth int) int {
+	if depth > maxSizeDepth {
+		return numberSize
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return 4
+	case reflect.String:
+		return v.Len() + 2
+	case reflect.Bool:
+		return 5
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intSize(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var buf [20]byte
+		return len(strconv.AppendUint(buf[:0], v.Uint(), 10))
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return 4
+		}
+		return reflectSize(v.Elem(), depth+1)
+	case reflect.Slice:
+		if v.IsNil() {
+			return 4
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodedLen(v.Len()) + 2
+		}
+		return elementsSize(v, depth)
+	case reflect.Array:
+		return elementsSize(v, depth)
+	case reflect.Map:
+		size := 2
+		iter := v.MapRange()
+		for iter.Next() {
+			size += reflectSize(iter.Key(), depth+1) + 3 + reflectSize(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Struct:
+		size := 2
+		typ := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if typ.Field(i).IsExported() {
+				size += len(typ.Field(i).Name) + 4 + reflectSize(v.Field(i), depth+1)
+			}
+		}
+		return size
+	default:
+		return numberSize
+	}
+}
+
+// elementsSize estimates the encoded size of a slice or array
+func elementsSize(v reflect.Value, depth int) int {
+	size := 2
+	for i := 0; i < v.Len(); i++ {
+		size += reflectSize(v.Index(i), depth+1) + 1
+	}
+	return size
+}
+
+// intSize returns the number of characters of n in decimal
+func intSize(n int64) int {
+	var buf [20]byte
+	return len(strconv.AppendInt(buf[:0], n, 10))
+}
+
+// makeRoom applies Config.BufferPolicy for an entry of size bytes. It
+// reports whether the entry may be appended, whether this is the first
+// drop since the buffer was last under its limit, and the error to return
+// for a rejected entry. Must be called with mutex held.
+func (w *Writer) makeRoom(size int) (ok, crossed bool, err error) {
+	limit := w.config.MaxBufferBytes
+	if limit <= 0 || w.bufferBytes+size <= limit {
+		return true, false, nil
+	}
+
+	crossed = !w.overLimit
+	w.overLimit = true
+
+	if w.config.BufferPolicy == BufferDropOldest && size <= limit {
+		dropped := 0
+		for len(w.buffer) > 0 && w.bufferBytes+size > limit {
+			w.bufferBytes -= w.buffer[0].size
+			w.wal.release(w.buffer[:1])
+			w.buffer[0] = LogEntry{}
+			w.buffer = w.buffer[1:]
+			dropped++
+		}
+		if dropped > 0 {
+			w.oldestBuffered = oldestTimestamp(w.buffer)
+		}
+		w.stats.memoryDropped.Add(uint64(dropped))
+		w.stats.dropped.Add(uint64(dropped))
+		return true, crossed, nil
+	}
+
+	w.stats.memoryDropped.Add(1)
+	w.stats.dropped.Add(1)
+	if w.config.BufferPolicy == BufferRejectNew {
+		return false, crossed, ErrBufferFull
+	}
+	return false, crossed, nil
+}
+
+// reportBufferFull notifies OnError the first time the buffer limit is hit
+func (w *Writer) reportBufferFull() {
+	w.handleError(fmt.Errorf("%w: MaxBufferBytes %d reached, dropping entries", ErrBufferFull, w.config.MaxBufferBytes))
+}
+
+// resetBufferBytes records that the buffer was emptied. Must be called
+// with mutex held.
+func (w *Writer) resetBufferBytes() {
+	w.bufferBytes = 0
+	w.overLimit = false
+	w.oldestBuffered = 0
+}
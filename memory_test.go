@@ -0,0 +1,188 @@
+// memory_test.go: Buffer memory bound tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+// newOutageWriter returns a writer whose endpoint answers 429 with a long
+// Retry-After, so entries accumulate in the buffer as during an outage
+func newOutageWriter(t *testing.T, policy BufferPolicy, errs *[]error) *Writer {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	writer, err := New(Config{
+		APIKey:         "test-api-key",
+		Site:           strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		MaxBufferBytes: 4096,
+		BufferPolicy:   policy,
+		OnError:        func(err error) { *errs = append(*errs, err) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = writer.DrainBuffer()
+		_ = writer.Close()
+	})
+	return writer
+}
+
+func TestWriter_MaxBufferBytesDuringOutage(t *testing.T) {
+	var errs []error
+	writer := newOutageWriter(t, BufferDropOldest, &errs)
+
+	for i := 0; i < 1000; i++ {
+		if err := writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("entry %04d %s", i, strings.Repeat("x", 100)))); err != nil {
+			t.Fatalf("WriteRecord() error = %v", err)
+		}
+	}
+
+	stats := writer.Stats()
+	if stats.BufferedBytes > 4096 {
+		t.Errorf("BufferedBytes = %d, want at most MaxBufferBytes", stats.BufferedBytes)
+	}
+	if stats.EntriesDroppedMemory == 0 || stats.EntriesDroppedMemory > stats.EntriesDropped {
+		t.Errorf("EntriesDroppedMemory = %d (EntriesDropped %d), want drops counted", stats.EntriesDroppedMemory, stats.EntriesDropped)
+	}
+
+	var full int
+	for _, err := range errs {
+		if errors.Is(err, ErrBufferFull) {
+			full++
+		}
+	}
+	if full != 1 {
+		t.Errorf("OnError received %d ErrBufferFull reports, want 1 per threshold crossing", full)
+	}
+
+	buffered := writer.Snapshot()
+	if last := buffered[len(buffered)-1].Message; !strings.HasPrefix(last, "entry 0999") {
+		t.Errorf("newest entry = %q, BufferDropOldest must keep recent entries", last)
+	}
+}
+
+func TestWriter_BufferRejectNew(t *testing.T) {
+	var errs []error
+	writer := newOutageWriter(t, BufferRejectNew, &errs)
+
+	var rejected int
+	for i := 0; i < 200; i++ {
+		err := writer.WriteRecord(iris.NewRecord(iris.Info, fmt.Sprintf("entry %04d %s", i, strings.Repeat("x", 100))))
+		if errors.Is(err, ErrBufferFull) {
+			rejected++
+		}
+	}
+
+	if rejected == 0 || uint64(rejected) != writer.Stats().EntriesDroppedMemory {
+		t.Errorf("rejected %d writes, EntriesDroppedMemory = %d", rejected, writer.Stats().EntriesDroppedMemory)
+	}
+	if first := writer.Snapshot()[0].Message; !strings.HasPrefix(first, "entry 0000") {
+		t.Errorf("oldest entry = %q, BufferRejectNew must keep old entries", first)
+	}
+}
+
+func TestEntrySize_EstimateTracksEncodedSize(t *testing.T) {
+	entries := []LogEntry{
+		{Timestamp: 1757152530123, Level: "info", Message: "ok"},
+		{
+			Timestamp: 1757152530123, Level: "error", Message: strings.Repeat("payment declined ", 20),
+			Service: "billing", Source: "go", Hostname: "web-01", Env: "prod", Version: "1.4.2",
+			Tags: "env:prod,team:payments,region:eu-west-1",
+			Fields: map[string]any{
+				"user_id":  12345,
+				"amount":   99.95,
+				"retried":  true,
+				"regions":  []string{"eu", "us"},
+				"http":     map[string]any{"method": "POST", "status_code": 402, "url": "/v1/charges"},
+				"attempts": []any{1, "two", false},
+			},
+		},
+	}
+	for i, entry := range entries {
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		estimate, actual := entrySize(entry), len(encoded)
+		if estimate < actual || estimate > actual*3/2+entryOverhead {
+			t.Errorf("entry %d: estimate %d, encoded %d; want estimate >= encoded within 50%%", i, estimate, actual)
+		}
+	}
+}
+
+func TestEntrySize_LargeArrays(t *testing.T) {
+	large := make([]int64, 10000)
+	for i := range large {
+		large[i] = int64(i) * 1000003
+	}
+	entry := LogEntry{
+		Timestamp: 1757152530123, Level: "info", Message: "upload",
+		Fields: map[string]any{
+			"payload":  make([]byte, 100*1024),
+			"samples":  large,
+			"counts":   map[string][]uint16{"a": {1, 2, 3}, "b": make([]uint16, 500)},
+			"matrix":   [][]float32{{1.5, 2.5}, {3.5}},
+			"checksum": [4]uint32{1, 2, 3, 4},
+		},
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	estimate, actual := entrySize(entry), len(encoded)
+	if estimate < actual || estimate > actual*3/2 {
+		t.Errorf("estimate %d, encoded %d; want estimate >= encoded within 50%%", estimate, actual)
+	}
+}
+
+func TestWriter_FlushAtBytes(t *testing.T) {
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		batches = append(batches, len(entries))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1000,
+		FlushInterval: time.Hour,
+		FlushAtBytes:  2048,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	message := strings.Repeat("x", 400)
+	for i := 0; i < 10; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, message))
+	}
+	_ = writer.Close()
+
+	if len(batches) < 2 || batches[0] >= 10 {
+		t.Errorf("batches = %v, want size-triggered flushes well before BatchSize", batches)
+	}
+}
@@ -0,0 +1,89 @@
+// minimal.go: Compact payloads that omit attributes Datadog defaults
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+)
+
+// defaultStatus is the status Datadog assigns to logs sent without one
+const defaultStatus = "info"
+
+// validatePayloadDefaults rejects Config.PayloadDefaults keys whose
+// omission Datadog cannot recover from
+func validatePayloadDefaults(defaults map[string]any) error {
+	for _, key := range []string{"timestamp", "message", "status"} {
+		if _, ok := defaults[key]; ok {
+			return fmt.Errorf("PayloadDefaults cannot omit %q", key)
+		}
+	}
+	return nil
+}
+
+// minimalEntries returns copies of entries for Config.MinimalPayload,
+// without info statuses and every attribute equal to its
+// Config.PayloadDefaults value. Buffered entries are not modified.
+func (w *Writer) minimalEntries(entries []LogEntry) []LogEntry {
+	minimal := make([]LogEntry, len(entries))
+	for i, entry := range entries {
+		if entry.Level == defaultStatus {
+			entry.Level = ""
+		}
+		if len(w.config.PayloadDefaults) > 0 {
+			w.omitDefaults(&entry)
+		}
+		minimal[i] = entry
+	}
+	return minimal
+}
+
+// omitDefaults clears the standard attributes and drops the fields that
+// equal their Config.PayloadDefaults value
+func (w *Writer) omitDefaults(entry *LogEntry) {
+	defaults := w.config.PayloadDefaults
+	for key, value := range map[string]*string{
+		"service":  &entry.Service,
+		"ddsource": &entry.Source,
+		"ddtags":   &entry.Tags,
+		"hostname": &entry.Hostname,
+		"env":      &entry.Env,
+		"version":  &entry.Version,
+	} {
+		if def, ok := defaults[key]; ok && def == *value {
+			*value = ""
+		}
+	}
+
+	var fields map[string]any // Copied on the first omitted field
+	for key, value := range entry.Fields {
+		def, ok := defaults[key]
+		if !ok || !comparableEqual(def, value) {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]any, len(entry.Fields))
+			for k, v := range entry.Fields {
+				fields[k] = v
+			}
+		}
+		delete(fields, key)
+	}
+	if fields != nil {
+		entry.Fields = fields
+	}
+}
+
+// comparableEqual reports whether a and b are equal, treating values of
+// uncomparable types such as slices and maps as different
+func comparableEqual(a, b any) (equal bool) {
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+	return a == b
+}
@@ -0,0 +1,97 @@
+// minimal_test.go: Minimal payload tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func minimalTestEntries() []LogEntry {
+	return []LogEntry{
+		{
+			Timestamp: 1757152530123, Level: "info", Message: "request served",
+			Service: "api", Source: "go", Hostname: "web-01", Env: "prod",
+			Fields: map[string]any{"region": "eu-west-1", "retried": false, "path": "/v1/orders"},
+		},
+		{
+			Timestamp: 1757152530124, Level: "error", Message: "upstream failed",
+			Service: "api", Source: "go", Hostname: "web-01", Env: "prod",
+			Fields: map[string]any{"region": "us-east-1", "retried": true, "ids": []int{1, 2}},
+		},
+	}
+}
+
+func TestWriter_MinimalPayload(t *testing.T) {
+	writer := &Writer{config: Config{
+		MinimalPayload:  true,
+		PayloadDefaults: map[string]any{"ddsource": "go", "region": "eu-west-1", "retried": false, "ids": []int{1, 2}},
+	}}
+	entries := minimalTestEntries()
+
+	payload, err := json.Marshal(writer.minimalEntries(entries))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("invalid payload %s: %v", payload, err)
+	}
+
+	for _, key := range []string{"status", "ddsource", "region", "retried"} {
+		if _, ok := got[0][key]; ok {
+			t.Errorf("entry 0 kept default %q: %v", key, got[0])
+		}
+	}
+	if got[1]["status"] != "error" || got[1]["region"] != "us-east-1" || got[1]["retried"] != true {
+		t.Errorf("entry 1 lost non-default values: %v", got[1])
+	}
+	if _, ok := got[1]["ids"]; !ok {
+		t.Error("uncomparable values must never be treated as defaults")
+	}
+	if got[0]["service"] != "api" || got[0]["hostname"] != "web-01" {
+		t.Errorf("attributes without a default were dropped: %v", got[0])
+	}
+	if entries[0].Level != "info" || entries[0].Source != "go" || entries[0].Fields["region"] != "eu-west-1" {
+		t.Errorf("buffered entry was modified: %+v", entries[0])
+	}
+}
+
+func TestNew_PayloadDefaultsRejectsRequiredAttributes(t *testing.T) {
+	_, err := New(Config{APIKey: "test-api-key", PayloadDefaults: map[string]any{"timestamp": 0}})
+	if err == nil || !strings.Contains(err.Error(), "timestamp") {
+		t.Errorf("New() error = %v, want timestamp rejected", err)
+	}
+}
+
+func benchmarkPayload(b *testing.B, minimal bool) {
+	writer := &Writer{config: Config{
+		MinimalPayload:  minimal,
+		PayloadDefaults: map[string]any{"ddsource": "go", "env": "prod", "region": "eu-west-1", "retried": false},
+	}}
+	entries := make([]LogEntry, 0, 100)
+	for len(entries) < 100 {
+		entries = append(entries, minimalTestEntries()[0])
+	}
+
+	var size int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var payload []byte
+		if minimal {
+			payload, _ = json.Marshal(writer.minimalEntries(entries))
+		} else {
+			payload, _ = json.Marshal(entries)
+		}
+		size = len(payload)
+	}
+	b.ReportMetric(float64(size)/float64(len(entries)), "bytes/entry")
+}
+
+func BenchmarkPayload_Full(b *testing.B)    { benchmarkPayload(b, false) }
+func BenchmarkPayload_Minimal(b *testing.B) { benchmarkPayload(b, true) }
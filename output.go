@@ -0,0 +1,81 @@
+// output.go: Agent-collect output mode for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// OutputMode selects where the writer delivers log entries
+type OutputMode int
+
+const (
+	// OutputIntake batches entries and ships them to the Datadog HTTP intake
+	OutputIntake OutputMode = iota
+
+	// OutputStdout writes one JSON entry per line to Config.OutputWriter
+	// (os.Stdout by default) for collection by the Datadog Agent
+	OutputStdout
+
+	// OutputSyslogTCP streams RFC 5424 messages to Config.SyslogAddress
+	// over TCP, for Agents collecting logs through a syslog listener
+	OutputSyslogTCP
+
+	// OutputSyslogUDP sends RFC 5424 messages to Config.SyslogAddress as
+	// UDP datagrams
+	OutputSyslogUDP
+)
+
+// String returns the output mode name
+func (m OutputMode) String() string {
+	switch m {
+	case OutputIntake:
+		return "intake"
+	case OutputStdout:
+		return "stdout"
+	case OutputSyslogTCP:
+		return "syslog-tcp"
+	case OutputSyslogUDP:
+		return "syslog-udp"
+	default:
+		return fmt.Sprintf("OutputMode(%d)", int(m))
+	}
+}
+
+// writeLine encodes a single entry as one JSON line in the format the
+// Datadog Agent collects from container output
+func (w *Writer) writeLine(entry LogEntry) error {
+	if w.config.EmitEntryChecksum {
+		if err := w.stampChecksum(&entry); err != nil {
+			w.handleError(err)
+			w.stats.dropped.Add(1)
+			return err
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal log entry: %w", err)
+		w.handleError(err)
+		w.stats.dropped.Add(1)
+		return err
+	}
+	line = append(line, '\n')
+
+	w.outputMutex.Lock()
+	_, err = w.config.OutputWriter.Write(line)
+	w.outputMutex.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to write log entry: %w", err)
+		w.handleError(err)
+		w.stats.dropped.Add(1)
+		return err
+	}
+	w.stats.sent.Add(1)
+	return nil
+}
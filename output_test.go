@@ -0,0 +1,68 @@
+// output_test.go: Agent-collect output mode tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_OutputStdout(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := New(Config{
+		Output:       OutputStdout,
+		OutputWriter: &out,
+		Service:      "agent-collect",
+		Tags:         map[string]string{"team": "core"},
+	})
+	if err != nil {
+		t.Fatalf("New() without API key in stdout mode failed: %v", err)
+	}
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "first"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "second"))
+
+	if err := writer.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), out.String())
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("Line is not valid JSON: %v", err)
+	}
+	expected := map[string]any{
+		"message":  "second",
+		"status":   "error",
+		"service":  "agent-collect",
+		"ddsource": "go",
+		"ddtags":   "team:core",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+
+	if got := writer.Stats().EntriesSent; got != 2 {
+		t.Errorf("EntriesSent = %d, want 2", got)
+	}
+}
+
+func TestNew_IntakeRequiresAPIKey(t *testing.T) {
+	if _, err := New(Config{Output: OutputIntake}); err == nil {
+		t.Error("Expected an error without API key in intake mode")
+	}
+}
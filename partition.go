@@ -0,0 +1,174 @@
+// partition.go: Per-tenant buffers so noisy partitions do not delay quiet ones
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/agilira/iris"
+)
+
+// defaultMaxPartitions bounds the partitions created for PartitionField
+const defaultMaxPartitions = 16
+
+// partitionWALPrefix names the WAL subdirectory of each partition, followed
+// by the hex-encoded partition key
+const partitionWALPrefix = "partition-"
+
+// root returns the writer whose request slots, retry budget, lifetime
+// request count, cooldown, DNS circuit, health and tags a partition
+// shares: its parent, or w itself for a top-level writer
+func (w *Writer) root() *Writer {
+	if w.parent != nil {
+		return w.parent
+	}
+	return w
+}
+
+// partitionFor returns the writer that buffers record: the partition for
+// its Config.PartitionField value, or w itself, the default partition, for
+// records without the field, once MaxPartitions is reached, or after Close
+func (w *Writer) partitionFor(record *iris.Record) *Writer {
+	if w.config.PartitionField == "" {
+		return w
+	}
+	key, ok := lookupString(record, w.config.PartitionField)
+	if !ok || key == "" {
+		return w
+	}
+
+	w.partitionsMutex.Lock()
+	defer w.partitionsMutex.Unlock()
+
+	if partition, ok := w.partitions[key]; ok {
+		return partition
+	}
+	if w.closed.Load() {
+		return w
+	}
+	if len(w.partitions) >= w.config.MaxPartitions {
+		w.stats.partitionOverflow.Add(1)
+		return w
+	}
+
+	partition, err := w.newPartition(key)
+	if err != nil {
+		w.handleError(fmt.Errorf("partition %q: %w", key, err))
+		return w
+	}
+	return partition
+}
+
+// newPartition creates and registers the partition for key. Must be
+// called with partitionsMutex held.
+func (w *Writer) newPartition(key string) (*Writer, error) {
+	partition, err := newWriter(w.partitionConfig(key), w)
+	if err != nil {
+		return nil, err
+	}
+	if w.partitions == nil {
+		w.partitions = make(map[string]*Writer)
+	}
+	w.partitions[key] = partition
+	return partition, nil
+}
+
+// restorePartitions creates a partition for every partition WAL directory
+// left by a previous process, so their entries are replayed at startup
+// instead of only once the same key is seen again. Restored partitions
+// count towards MaxPartitions.
+func (w *Writer) restorePartitions() {
+	dirs, err := filepath.Glob(filepath.Join(w.config.WALDir, partitionWALPrefix+"*"))
+	if err != nil {
+		w.handleError(fmt.Errorf("WAL: failed to list partitions: %w", err))
+		return
+	}
+
+	w.partitionsMutex.Lock()
+	defer w.partitionsMutex.Unlock()
+
+	for _, dir := range dirs {
+		key, err := hex.DecodeString(strings.TrimPrefix(filepath.Base(dir), partitionWALPrefix))
+		if err != nil || len(key) == 0 {
+			continue
+		}
+		if _, err := w.newPartition(string(key)); err != nil {
+			w.handleError(fmt.Errorf("partition %q: %w", key, err))
+		}
+	}
+}
+
+// partitionConfig derives the config of a new partition: the same
+// settings, sharing the HTTP client, without further partitioning or its
+// own runtime stats. Its WAL lives in a subdirectory named after the key.
+func (w *Writer) partitionConfig(key string) Config {
+	config := w.config
+	if config.WALDir != "" {
+		config.WALDir = filepath.Join(config.WALDir, partitionWALPrefix+hex.EncodeToString([]byte(key)))
+	}
+	config.PartitionField = ""
+	config.RuntimeStatsInterval = 0
+	config.AdditionalDestinations = nil
+	config.Profiles = nil
+	config.ActiveProfile = ""
+	config.HTTPClient = w.client
+	config.Warmup = false
+	config.ResolveHostOnStart = false
+	return config
+}
+
+// partitionList returns the current partitions in key order
+func (w *Writer) partitionList() []*Writer {
+	w.partitionsMutex.Lock()
+	defer w.partitionsMutex.Unlock()
+
+	list := make([]*Writer, 0, len(w.partitions))
+	for _, key := range slices.Sorted(maps.Keys(w.partitions)) {
+		list = append(list, w.partitions[key])
+	}
+	return list
+}
+
+// flushPartitions flushes every partition and joins their errors
+func (w *Writer) flushPartitions() error {
+	var errs []error
+	for _, partition := range w.partitionList() {
+		errs = append(errs, partition.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// closePartitions closes every partition and joins their errors
+func (w *Writer) closePartitions() error {
+	var errs []error
+	for _, partition := range w.partitionList() {
+		errs = append(errs, partition.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// addCounters sums the numeric counters of o into s
+func (s *Stats) addCounters(o Stats) {
+	dst := reflect.ValueOf(s).Elem()
+	src := reflect.ValueOf(o)
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Field(i)
+		switch field.Kind() {
+		case reflect.Uint64:
+			field.SetUint(field.Uint() + src.Field(i).Uint())
+		case reflect.Int, reflect.Int64:
+			field.SetInt(field.Int() + src.Field(i).Int())
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// partition_test.go: Per-partition buffering tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func tenantRecord(tenant, msg string) *iris.Record {
+	record := iris.NewRecord(iris.Info, msg)
+	if tenant != "" {
+		record.AddField(iris.Str("tenant", tenant))
+	}
+	return record
+}
+
+func TestWriter_PartitionField(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:    true,
+		BatchSize:      2,
+		FlushInterval:  time.Hour,
+		PartitionField: "tenant",
+		MaxPartitions:  1,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_ = writer.WriteRecord(tenantRecord("noisy", "n1"))
+	_ = writer.WriteRecord(tenantRecord("quiet", "q1"))
+	_ = writer.WriteRecord(tenantRecord("noisy", "n2"))
+	_ = writer.WriteRecord(tenantRecord("", "untagged"))
+
+	noisy := writer.partitions["noisy"]
+	if noisy == nil {
+		t.Fatal("no partition created for noisy")
+	}
+	if batches := noisy.CapturedBatches(); len(batches) != 1 || len(batches[0].Entries) != 2 {
+		t.Errorf("noisy batches = %+v, want one full batch of its own", batches)
+	}
+	if _, ok := writer.partitions["quiet"]; ok {
+		t.Error("quiet got a partition beyond MaxPartitions")
+	}
+	// The default partition's batches come first, then each partition's
+	if batches := writer.CapturedBatches(); len(batches) != 2 || batches[0].Entries[0].Message != "q1" || batches[0].Entries[1].Message != "untagged" || batches[1].Entries[0].Message != "n1" {
+		t.Errorf("batches = %+v, want overflow and untagged entries together, then noisy's", batches)
+	}
+
+	_ = writer.WriteRecord(tenantRecord("noisy", "n3"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := len(noisy.CapturedBatches()); got != 2 {
+		t.Errorf("noisy batches after Close = %d, want 2", got)
+	}
+
+	stats := writer.Stats()
+	if stats.EntriesSent != 5 || stats.Partitions != 1 || stats.PartitionOverflow != 1 {
+		t.Errorf("EntriesSent = %d, Partitions = %d, PartitionOverflow = %d, want 5, 1, 1", stats.EntriesSent, stats.Partitions, stats.PartitionOverflow)
+	}
+	if err := writer.WriteRecord(tenantRecord("noisy", "late")); err != ErrWriterClosed {
+		t.Errorf("WriteRecord after Close = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestWriter_PartitionsShareLimits(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:               "test-key",
+		Site:                 strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:            1,
+		FlushInterval:        time.Hour,
+		PartitionField:       "tenant",
+		MaxLifetimeRequests:  2,
+		RuntimeStatsInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for _, tenant := range []string{"a", "b", "c"} {
+		_ = writer.WriteRecord(tenantRecord(tenant, "hello"))
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want MaxLifetimeRequests 2 across partitions", got)
+	}
+	if stats := writer.Stats(); stats.LifetimeRequestsRemaining != 0 || stats.Requests != 2 {
+		t.Errorf("LifetimeRequestsRemaining = %d, Requests = %d, want 0, 2", stats.LifetimeRequestsRemaining, stats.Requests)
+	}
+	if got := writer.partitions["a"].config.RuntimeStatsInterval; got != 0 {
+		t.Errorf("partition RuntimeStatsInterval = %v, want 0", got)
+	}
+}
+
+func TestWriter_PartitionsShareCooldownAndHealth(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:                          "test-key",
+		Site:                            strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:                       1,
+		FlushInterval:                   time.Hour,
+		MaxRetries:                      1,
+		RetryDelay:                      time.Millisecond,
+		PartitionField:                  "tenant",
+		DisableAfterConsecutiveFailures: 1,
+		ProbeInterval:                   time.Hour,
+		OnError:                         func(error) {},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(tenantRecord("a", "rate limited"))
+	_ = writer.WriteRecord(tenantRecord("b", "held back"))
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests during cooldown = %d, want 1", got)
+	}
+	if got := len(writer.Snapshot()); got != 2 {
+		t.Errorf("Snapshot() = %d entries, want both partitions' 2", got)
+	}
+
+	writer.cooldownUntil.Store(0)
+	_ = writer.Flush()
+	if writer.Healthy() {
+		t.Error("Healthy() = true, want a failing partition to disable the writer")
+	}
+	if got := len(writer.Snapshot()); got != 0 {
+		t.Errorf("Snapshot() after disable = %d entries, want 0", got)
+	}
+	writer.Resume()
+	if !writer.Healthy() {
+		t.Error("Healthy() = false after Resume")
+	}
+}
+
+func TestWriter_PartitionsFollowUpdateTags(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:    true,
+		FlushInterval:  time.Hour,
+		PartitionField: "tenant",
+		Tags:           map[string]string{"env": "staging"},
+		DebugRingSize:  10,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(tenantRecord("old", "before"))
+	writer.UpdateTags(map[string]string{"env": "prod"})
+	_ = writer.WriteRecord(tenantRecord("old", "after"))
+	_ = writer.WriteRecord(tenantRecord("new", "after"))
+
+	entries := writer.DrainBuffer()
+	if len(entries) != 3 {
+		t.Fatalf("DrainBuffer() = %d entries, want 3 from the partitions", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Message == "after" && entry.Tags != "env:prod" {
+			t.Errorf("%s entry tags = %q, want env:prod", entry.Fields["tenant"], entry.Tags)
+		}
+	}
+	if got := len(writer.ring.snapshot()); got != 3 {
+		t.Errorf("debug ring = %d entries, want partition entries included", got)
+	}
+}
+
+func TestWriter_PartitionWALReplayedAtStartup(t *testing.T) {
+	var healthy atomic.Bool
+	var delivered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var entries []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		delivered.Add(int32(len(entries)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	config := Config{
+		APIKey:         "test-key",
+		Site:           strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:  time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+		WALDir:         t.TempDir(),
+		PartitionField: "tenant",
+		OnError:        func(error) {},
+	}
+
+	first, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = first.WriteRecord(tenantRecord("acme", "one"))
+	_ = first.WriteRecord(tenantRecord("acme", "two"))
+	_ = first.Close()
+
+	// The restarted writer never sees tenant acme again
+	healthy.Store(true)
+	second, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := second.Stats().WALReplayed; got != 2 {
+		t.Errorf("WALReplayed = %d, want 2", got)
+	}
+	if got := delivered.Load(); got != 2 {
+		t.Errorf("delivered = %d, want 2", got)
+	}
+}
+
+func TestWriter_PartitionsShareResponsesAndServiceReports(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Datadog-Request-Id", fmt.Sprintf("req-%d", requests.Add(1)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var reports atomic.Int32
+	writer, err := New(Config{
+		APIKey:          "test-key",
+		Site:            strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:       1,
+		FlushInterval:   time.Hour,
+		PartitionField:  "tenant",
+		Service:         "gateway",
+		ServiceField:    "svc",
+		AllowedServices: []string{"billing"},
+		OnError:         func(error) { reports.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for _, tenant := range []string{"a", "b"} {
+		record := tenantRecord(tenant, "hello")
+		record.AddField(iris.Str("svc", "rogue"))
+		_ = writer.WriteRecord(record)
+	}
+
+	if got := len(writer.RecentResponses()); got != 2 {
+		t.Errorf("RecentResponses() = %d, want both partitions' 2", got)
+	}
+	if got := writer.Stats().LastRequestID; got != "req-2" {
+		t.Errorf("LastRequestID = %q, want req-2", got)
+	}
+	if got := reports.Load(); got != 1 {
+		t.Errorf("OnError reports = %d, want the disallowed service reported once", got)
+	}
+}
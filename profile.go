@@ -0,0 +1,62 @@
+// profile.go: Named per-environment site/key profiles
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+	"os"
+)
+
+// Profile holds the settings that usually differ between environments
+type Profile struct {
+	// Datadog site for this profile (e.g. "datadoghq.eu")
+	Site string
+
+	// API key for this profile
+	APIKey string
+
+	// Tags merged over Config.Tags, profile values win on conflicts
+	Tags map[string]string
+}
+
+// applyProfile resolves the active profile into config. The profile is
+// Config.ActiveProfile or, when that is empty and profiles are defined,
+// the DD_PROFILE environment variable. Non-empty profile values override
+// the shared Config values.
+func applyProfile(config *Config) error {
+	name := config.ActiveProfile
+	if name == "" && len(config.Profiles) > 0 {
+		name = os.Getenv("DD_PROFILE")
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q is not defined", name)
+	}
+
+	if profile.Site != "" {
+		config.Site = profile.Site
+	}
+	if profile.APIKey != "" {
+		config.APIKey = profile.APIKey
+	}
+	if len(profile.Tags) > 0 {
+		tags := make(map[string]string, len(config.Tags)+len(profile.Tags))
+		for key, value := range config.Tags {
+			tags[key] = value
+		}
+		for key, value := range profile.Tags {
+			tags[key] = value
+		}
+		config.Tags = tags
+	}
+	config.ActiveProfile = name
+	return nil
+}
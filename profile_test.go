@@ -0,0 +1,73 @@
+// profile_test.go: Named per-environment profile tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+)
+
+func profilesConfig() Config {
+	return Config{
+		Service: "profiled-service",
+		Tags:    map[string]string{"team": "core", "tier": "base"},
+		Profiles: map[string]Profile{
+			"dev":  {Site: "localhost:1", APIKey: "dev-key"},
+			"prod": {Site: "datadoghq.eu", APIKey: "prod-key", Tags: map[string]string{"tier": "prod"}},
+		},
+	}
+}
+
+func TestNew_ActiveProfile(t *testing.T) {
+	config := profilesConfig()
+	config.ActiveProfile = "prod"
+
+	writer, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if writer.config.Site != "datadoghq.eu" || writer.config.APIKey != "prod-key" {
+		t.Errorf("Site/APIKey = %q/%q, want prod profile values", writer.config.Site, writer.config.APIKey)
+	}
+	if got, want := writer.currentTags().ddtags, "team:core,tier:prod"; got != want {
+		t.Errorf("ddtags = %q, want %q", got, want)
+	}
+}
+
+func TestNew_ProfileFromEnv(t *testing.T) {
+	t.Setenv("DD_PROFILE", "dev")
+
+	writer, err := New(profilesConfig())
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if writer.config.APIKey != "dev-key" || writer.config.ActiveProfile != "dev" {
+		t.Errorf("APIKey/ActiveProfile = %q/%q, want dev profile", writer.config.APIKey, writer.config.ActiveProfile)
+	}
+}
+
+func TestNew_UnknownProfile(t *testing.T) {
+	config := profilesConfig()
+	config.ActiveProfile = "staging"
+
+	if _, err := New(config); err == nil {
+		t.Error("Expected error for undefined profile")
+	}
+}
+
+func TestNew_ProfileEnvIgnoredWithoutProfiles(t *testing.T) {
+	t.Setenv("DD_PROFILE", "dev")
+
+	writer, err := New(Config{APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+}
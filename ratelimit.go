@@ -0,0 +1,65 @@
+// ratelimit.go: Adaptive throttling from Datadog rate-limit headers
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the latest rate-limit state reported by the intake
+type RateLimit struct {
+	// Limit is the number of requests allowed per period
+	Limit int
+
+	// Remaining is the number of requests left in the current period
+	Remaining int
+
+	// Reset is the time until the period resets
+	Reset time.Duration
+
+	// Time is when the headers were received
+	Time time.Time
+}
+
+// rateLimitLowFraction is the share of Limit at or below which Remaining
+// counts as low and sends pause until the period resets
+const rateLimitLowFraction = 10
+
+// observeRateLimit records the X-RateLimit-* headers of a response and,
+// when few requests remain, pauses sends until the period resets
+func (w *Writer) observeRateLimit(header http.Header) {
+	limit, okLimit := headerInt(header, "X-RateLimit-Limit")
+	remaining, okRemaining := headerInt(header, "X-RateLimit-Remaining")
+	if !okLimit || !okRemaining {
+		return
+	}
+	reset, _ := headerInt(header, "X-RateLimit-Reset")
+
+	snapshot := &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Duration(reset) * time.Second,
+		Time:      time.Now(),
+	}
+	w.root().rateLimit.Store(snapshot)
+
+	if snapshot.Reset > 0 && remaining*rateLimitLowFraction <= limit {
+		w.startCooldown(snapshot.Reset)
+		w.stats.rateLimitThrottles.Add(1)
+	}
+}
+
+// headerInt parses a non-negative integer header
+func headerInt(header http.Header, name string) (int, bool) {
+	value, err := strconv.Atoi(header.Get(name))
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return value, true
+}
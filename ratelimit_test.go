@@ -0,0 +1,72 @@
+// ratelimit_test.go: Rate-limit header throttling tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_RespectRateLimitHeaders(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "5")
+		w.Header().Set("X-RateLimit-Reset", "30")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:                  "test-key",
+		Site:                    strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:               1,
+		FlushInterval:           time.Hour,
+		RespectRateLimitHeaders: true,
+		DrainTimeout:            100 * time.Millisecond, // Close gives up on the 30s pause
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "first"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "held"))
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1 while throttled", got)
+	}
+	stats := writer.Stats()
+	if stats.RateLimit.Limit != 100 || stats.RateLimit.Remaining != 5 || stats.RateLimit.Reset != 30*time.Second {
+		t.Errorf("RateLimit = %+v, want limit 100, remaining 5, reset 30s", stats.RateLimit)
+	}
+	if stats.RateLimitThrottles != 1 || len(writer.Snapshot()) != 1 {
+		t.Errorf("RateLimitThrottles = %d, buffered = %d, want 1 and 1", stats.RateLimitThrottles, len(writer.Snapshot()))
+	}
+}
+
+func TestWriter_RateLimitPlentyRemaining(t *testing.T) {
+	writer := &Writer{}
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "100")
+	header.Set("X-RateLimit-Remaining", "90")
+	writer.observeRateLimit(header)
+
+	if writer.inCooldown() || writer.Stats().RateLimitThrottles != 0 {
+		t.Error("plenty of remaining requests must not throttle")
+	}
+	if writer.Stats().RateLimit.Remaining != 90 {
+		t.Errorf("RateLimit.Remaining = %d, want 90", writer.Stats().RateLimit.Remaining)
+	}
+}
@@ -0,0 +1,81 @@
+// resource.go: OpenTelemetry resource attribute mapping for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+// otelToDatadog translates common OpenTelemetry resource attribute keys to
+// the tag names used by Datadog's unified service tagging and integrations.
+// Keys that map to "service", "env", "version" or "host" populate the
+// corresponding reserved attributes rather than becoming tags.
+var otelToDatadog = map[string]string{
+	"service.name":                "service",
+	"service.version":             "version",
+	"deployment.environment":      "env",
+	"deployment.environment.name": "env",
+	"host.name":                   "host",
+	"k8s.cluster.name":            "kube_cluster_name",
+	"k8s.namespace.name":          "kube_namespace",
+	"k8s.pod.name":                "pod_name",
+	"k8s.deployment.name":         "kube_deployment",
+	"k8s.statefulset.name":        "kube_stateful_set",
+	"k8s.daemonset.name":          "kube_daemon_set",
+	"k8s.container.name":          "kube_container_name",
+	"container.id":                "container_id",
+	"container.name":              "container_name",
+	"container.image.name":        "image_name",
+	"container.image.tag":         "image_tag",
+	"cloud.provider":              "cloud_provider",
+	"cloud.region":                "region",
+	"cloud.availability_zone":     "zone",
+	"cloud.account.id":            "account_id",
+}
+
+// applyResourceAttributes merges Config.ResourceAttributes into the config.
+// Reserved attributes only fill fields that are still empty, and mapped
+// tags never override an explicit entry in Config.Tags. Attributes without
+// a known translation are kept as tags under their original key.
+func applyResourceAttributes(config *Config) {
+	if len(config.ResourceAttributes) == 0 {
+		return
+	}
+
+	tags := make(map[string]string, len(config.Tags)+len(config.ResourceAttributes))
+	for key, value := range config.Tags {
+		tags[key] = value
+	}
+
+	for key, value := range config.ResourceAttributes {
+		name, ok := otelToDatadog[key]
+		if !ok {
+			name = key
+		}
+
+		switch name {
+		case "service":
+			if config.Service == "" {
+				config.Service = value
+			}
+		case "env":
+			if config.Environment == "" {
+				config.Environment = value
+			}
+		case "version":
+			if config.Version == "" {
+				config.Version = value
+			}
+		case "host":
+			if config.Hostname == "" {
+				config.Hostname = value
+			}
+		default:
+			if _, exists := tags[name]; !exists {
+				tags[name] = value
+			}
+		}
+	}
+
+	config.Tags = tags
+}
@@ -0,0 +1,68 @@
+// resource_test.go: OpenTelemetry resource attribute mapping tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+)
+
+func TestApplyResourceAttributes(t *testing.T) {
+	callerTags := map[string]string{"region": "explicit-region"}
+	config := Config{
+		Service: "explicit-service",
+		Tags:    callerTags,
+		ResourceAttributes: map[string]string{
+			"service.name":           "otel-service",
+			"deployment.environment": "production",
+			"host.name":              "node-7",
+			"k8s.pod.name":           "api-5d9f",
+			"cloud.region":           "us-east-1",
+			"service.namespace":      "payments",
+		},
+	}
+
+	applyResourceAttributes(&config)
+
+	if config.Service != "explicit-service" {
+		t.Errorf("Service = %q, explicit config must win", config.Service)
+	}
+	if config.Environment != "production" {
+		t.Errorf("Environment = %q, want %q", config.Environment, "production")
+	}
+	if config.Hostname != "node-7" {
+		t.Errorf("Hostname = %q, want %q", config.Hostname, "node-7")
+	}
+
+	expected := map[string]string{
+		"region":            "explicit-region",
+		"pod_name":          "api-5d9f",
+		"service.namespace": "payments",
+	}
+	for key, value := range expected {
+		if config.Tags[key] != value {
+			t.Errorf("Tags[%q] = %q, want %q", key, config.Tags[key], value)
+		}
+	}
+	if len(callerTags) != 1 {
+		t.Errorf("Caller tag map was mutated: %v", callerTags)
+	}
+}
+
+func TestNew_ResourceAttributesTags(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:             "test-api-key",
+		ResourceAttributes: map[string]string{"k8s.namespace.name": "default"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if got := writer.currentTags().ddtags; got != "kube_namespace:default" {
+		t.Errorf("ddtags = %q, want %q", got, "kube_namespace:default")
+	}
+}
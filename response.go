@@ -0,0 +1,137 @@
+// response.go: Intake response tracking for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestIDHeaders are the response headers Datadog uses to identify a
+// request, checked in order
+var requestIDHeaders = []string{"X-Datadog-Request-Id", "Dd-Request-Id", "X-Request-Id"}
+
+// recentResponsesSize is the number of responses kept for RecentResponses
+const recentResponsesSize = 16
+
+// defaultMaxErrorBodyBytes bounds the error body captured from a failed response
+const defaultMaxErrorBodyBytes = 512
+
+// ResponseInfo describes a response from the Datadog intake
+type ResponseInfo struct {
+	// Time is when the response was received
+	Time time.Time
+
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+
+	// RequestID is the Datadog request identifier, useful for support tickets
+	RequestID string
+
+	// Timeout is the deadline the request was sent with (see
+	// Config.AdaptiveTimeout)
+	Timeout time.Duration
+
+	// ErrorBody is a single-line summary of the body of a failed response,
+	// at most Config.MaxErrorBodyBytes long
+	ErrorBody string
+}
+
+// Failed reports whether the response was not a 2xx
+func (r ResponseInfo) Failed() bool {
+	return r.StatusCode < 200 || r.StatusCode >= 300
+}
+
+// responseRing keeps the most recent responses
+type responseRing struct {
+	mu    sync.Mutex
+	items [recentResponsesSize]ResponseInfo
+	next  int
+	count int
+}
+
+func (r *responseRing) add(info ResponseInfo) {
+	r.mu.Lock()
+	r.items[r.next] = info
+	r.next = (r.next + 1) % len(r.items)
+	if r.count < len(r.items) {
+		r.count++
+	}
+	r.mu.Unlock()
+}
+
+// snapshot returns the stored responses, oldest first
+func (r *responseRing) snapshot() []ResponseInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]ResponseInfo, 0, r.count)
+	start := (r.next - r.count + len(r.items)) % len(r.items)
+	for i := 0; i < r.count; i++ {
+		out = append(out, r.items[(start+i)%len(r.items)])
+	}
+	return out
+}
+
+// RecentResponses returns up to the last 16 intake responses, oldest
+// first, across all partitions
+func (w *Writer) RecentResponses() []ResponseInfo {
+	return w.root().responses.snapshot()
+}
+
+// RecentErrors returns the failed responses among RecentResponses
+func (w *Writer) RecentErrors() []ResponseInfo {
+	var failed []ResponseInfo
+	for _, info := range w.RecentResponses() {
+		if info.Failed() {
+			failed = append(failed, info)
+		}
+	}
+	return failed
+}
+
+// recordResponse stores a response and passes it to Config.OnResponse
+func (w *Writer) recordResponse(resp *http.Response, errorBody string, timeout time.Duration) {
+	info := ResponseInfo{
+		Time:       time.Now(),
+		StatusCode: resp.StatusCode,
+		Timeout:    timeout,
+		ErrorBody:  errorBody,
+	}
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			info.RequestID = id
+			break
+		}
+	}
+
+	// Kept on the parent, so partitions' responses are included
+	root := w.root()
+	root.responses.add(info)
+	if info.RequestID != "" {
+		root.lastRequestID.Store(info.RequestID)
+	}
+	if w.config.OnResponse != nil {
+		w.config.OnResponse(info)
+	}
+}
+
+// readErrorBody captures a single-line summary of at most limit bytes of
+// an error response body. Reading goes through an io.LimitReader, so a
+// huge body is never pulled into memory.
+func readErrorBody(body io.Reader, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	summary := strings.Join(strings.Fields(string(data)), " ")
+	return truncateUTF8(summary, limit)
+}
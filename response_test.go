@@ -0,0 +1,127 @@
+// response_test.go: Intake response tracking tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_RequestIDCapture(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("X-Datadog-Request-Id", fmt.Sprintf("req-%d", n))
+		if n == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var observed []ResponseInfo
+	writer, err := New(Config{
+		APIKey:        "test-api-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		OnResponse:    func(info ResponseInfo) { observed = append(observed, info) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "rejected"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "accepted"))
+
+	if len(observed) != 2 || observed[0].RequestID != "req-1" || observed[1].RequestID != "req-2" {
+		t.Fatalf("OnResponse observed %+v", observed)
+	}
+
+	recent := writer.RecentResponses()
+	if len(recent) != 2 || recent[1].StatusCode != http.StatusAccepted {
+		t.Errorf("RecentResponses() = %+v", recent)
+	}
+
+	errors := writer.RecentErrors()
+	if len(errors) != 1 || errors[0].RequestID != "req-1" || errors[0].StatusCode != http.StatusBadRequest {
+		t.Errorf("RecentErrors() = %+v", errors)
+	}
+
+	if got := writer.Stats().LastRequestID; got != "req-2" {
+		t.Errorf("LastRequestID = %q, want %q", got, "req-2")
+	}
+}
+
+func TestResponseRing_Wraps(t *testing.T) {
+	var ring responseRing
+	for i := 0; i < recentResponsesSize+3; i++ {
+		ring.add(ResponseInfo{StatusCode: i})
+	}
+
+	items := ring.snapshot()
+	if len(items) != recentResponsesSize {
+		t.Fatalf("len = %d, want %d", len(items), recentResponsesSize)
+	}
+	if items[0].StatusCode != 3 || items[len(items)-1].StatusCode != recentResponsesSize+2 {
+		t.Errorf("Unexpected ring order: first %d, last %d", items[0].StatusCode, items[len(items)-1].StatusCode)
+	}
+}
+
+func TestWriter_ErrorBodyCapture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, "{\n  \"errors\": [\"invalid payload\"]\n}\n"+strings.Repeat("x", 1<<20))
+	}))
+	defer server.Close()
+
+	var errs []error
+	writer, err := New(Config{
+		APIKey:            "test-api-key",
+		Site:              strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:         1,
+		FlushInterval:     time.Hour,
+		MaxErrorBodyBytes: 64,
+		OnError:           func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "rejected"))
+
+	recent := writer.RecentErrors()
+	if len(recent) != 1 {
+		t.Fatalf("RecentErrors() = %+v", recent)
+	}
+	body := recent[0].ErrorBody
+	if len(body) > 64 || strings.Contains(body, "\n") {
+		t.Errorf("ErrorBody = %q, want a single line of at most 64 bytes", body)
+	}
+	if !strings.HasPrefix(body, `{ "errors": ["invalid payload"] }`) {
+		t.Errorf("ErrorBody = %q, want collapsed JSON summary", body)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "invalid payload") {
+		t.Errorf("OnError received %v, want error with body summary", errs)
+	}
+}
+
+func TestReadErrorBody_Disabled(t *testing.T) {
+	if got := readErrorBody(strings.NewReader("boom"), -1); got != "" {
+		t.Errorf("readErrorBody() = %q, want empty when disabled", got)
+	}
+}
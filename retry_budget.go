@@ -0,0 +1,66 @@
+// retry_budget.go: Shared retry budget for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"sync"
+)
+
+// defaultRetryBudgetBurst is the number of retries available up front,
+// so a short burst of failures can still be retried before the ratio
+// has had time to accumulate
+const defaultRetryBudgetBurst = 10
+
+// retryBudget is a token bucket shared by all batches. Every first attempt
+// deposits ratio tokens and every retry withdraws one, so over time
+// retries cannot exceed ratio times the number of requests.
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	burst  float64
+	tokens float64
+}
+
+func newRetryBudget(ratio float64, burst int) *retryBudget {
+	if burst <= 0 {
+		burst = defaultRetryBudgetBurst
+	}
+	return &retryBudget{
+		ratio:  ratio,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// onRequest credits the budget for a first attempt
+func (b *retryBudget) onRequest() {
+	b.mu.Lock()
+	b.tokens += b.ratio
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.mu.Unlock()
+}
+
+// allowRetry withdraws a token if one is available
+func (b *retryBudget) allowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// available returns the current number of tokens
+func (b *retryBudget) available() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
@@ -0,0 +1,79 @@
+// retry_budget_test.go: Shared retry budget tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestRetryBudget(t *testing.T) {
+	budget := newRetryBudget(0.25, 2)
+
+	if !budget.allowRetry() || !budget.allowRetry() {
+		t.Fatal("Expected the initial burst to allow 2 retries")
+	}
+	if budget.allowRetry() {
+		t.Fatal("Expected the budget to be exhausted")
+	}
+
+	for i := 0; i < 4; i++ {
+		budget.onRequest()
+	}
+	if !budget.allowRetry() {
+		t.Error("Expected 4 requests at ratio 0.25 to earn one retry")
+	}
+	if budget.allowRetry() {
+		t.Error("Expected the earned retry to be spent")
+	}
+}
+
+func TestWriter_RetryBudgetFailsFast(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:           "test-api-key",
+		Site:             strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:        1,
+		FlushInterval:    time.Hour,
+		MaxRetries:       3,
+		RetryDelay:       time.Millisecond,
+		RetryBudgetRatio: 0.1,
+		RetryBudgetBurst: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	// First batch: 1 attempt + 2 budgeted retries; second batch: 1 attempt only
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "first"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "second"))
+
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Requests = %d, want 4", got)
+	}
+
+	stats := writer.Stats()
+	if stats.RetriesDenied != 2 {
+		t.Errorf("RetriesDenied = %d, want 2", stats.RetriesDenied)
+	}
+	if stats.RetryBudget >= 1 {
+		t.Errorf("RetryBudget = %f, want exhausted", stats.RetryBudget)
+	}
+}
@@ -0,0 +1,66 @@
+// routing.go: Level-based routing markers for Datadog index filters
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"slices"
+
+	"github.com/agilira/iris"
+)
+
+// LevelRoute stamps routing markers on entries at or above MinLevel, so
+// Datadog index filters can split streams by severity (e.g. error+ to a
+// high-retention index)
+type LevelRoute struct {
+	// MinLevel is the lowest level the route applies to. An entry takes
+	// the route with the highest MinLevel it meets.
+	MinLevel iris.Level
+
+	// Source replaces the entry's ddsource when set
+	Source string
+
+	// Tags are merged into the entry's ddtags, replacing tags of the
+	// same key
+	Tags map[string]string
+}
+
+// sortLevelRoutes returns a copy of routes ordered by descending
+// MinLevel, so the first route an entry meets is the one it takes
+func sortLevelRoutes(routes []LevelRoute) []LevelRoute {
+	if len(routes) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(routes)
+	slices.SortStableFunc(sorted, func(a, b LevelRoute) int {
+		return int(b.MinLevel) - int(a.MinLevel)
+	})
+	return sorted
+}
+
+// levelRoute returns the route for level, or nil when none applies
+func (w *Writer) levelRoute(level iris.Level) *LevelRoute {
+	for i := range w.routes {
+		if level >= w.routes[i].MinLevel {
+			return &w.routes[i]
+		}
+	}
+	return nil
+}
+
+// applyLevelRoute stamps the routing markers of the entry's level route
+func (w *Writer) applyLevelRoute(level iris.Level, entry *LogEntry) {
+	route := w.levelRoute(level)
+	if route == nil {
+		return
+	}
+	if route.Source != "" {
+		entry.Source = route.Source
+	}
+	if len(route.Tags) > 0 {
+		entry.Tags = mergeTags(parseDDTags(entry.Tags), route.Tags, TagDynamicWins)
+	}
+}
@@ -0,0 +1,58 @@
+// routing_test.go: Level routing tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_LevelRouting(t *testing.T) {
+	writer, err := New(Config{
+		APIKey: "test-api-key",
+		Source: "go",
+		Tags:   map[string]string{"env": "prod", "retention": "default"},
+		LevelRouting: []LevelRoute{
+			{MinLevel: iris.Error, Source: "go-errors", Tags: map[string]string{"retention": "long"}},
+			{MinLevel: iris.Debug, Tags: map[string]string{"retention": "short"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	tests := []struct {
+		level  iris.Level
+		source string
+		tags   string
+	}{
+		{iris.Debug, "go", "env:prod,retention:short"},
+		{iris.Warn, "go", "env:prod,retention:short"},
+		{iris.Error, "go-errors", "env:prod,retention:long"},
+		{iris.Fatal, "go-errors", "env:prod,retention:long"},
+	}
+	for _, tt := range tests {
+		entry := writer.buildLogEntry(iris.NewRecord(tt.level, "routed"))
+		if entry.Source != tt.source || entry.Tags != tt.tags {
+			t.Errorf("%v: ddsource = %q, ddtags = %q; want %q, %q", tt.level, entry.Source, entry.Tags, tt.source, tt.tags)
+		}
+	}
+}
+
+func TestWriter_LevelRoutingUnmatched(t *testing.T) {
+	writer := &Writer{
+		config: Config{Source: "go"},
+		routes: sortLevelRoutes([]LevelRoute{{MinLevel: iris.Error, Source: "errors"}}),
+	}
+	entry := LogEntry{Source: "go", Tags: "env:prod"}
+	writer.applyLevelRoute(iris.Info, &entry)
+	if entry.Source != "go" || entry.Tags != "env:prod" {
+		t.Errorf("entry below every route changed: %+v", entry)
+	}
+}
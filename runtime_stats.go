@@ -0,0 +1,82 @@
+// runtime_stats.go: Periodic Go runtime statistics for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"runtime"
+	"time"
+	"weak"
+
+	"github.com/agilira/iris"
+)
+
+// runtimeStatsTag marks synthetic runtime entries so they can be told
+// apart from application logs in Datadog
+const runtimeStatsTag = "origin:runtime_stats"
+
+// runRuntimeStats buffers a runtime statistics entry every interval until
+// the writer is closed. Like the flush timer it holds only a weak
+// reference, so it does not keep an abandoned writer alive; it exits once
+// the writer was collected.
+func runRuntimeStats(ref weak.Pointer[Writer], interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w := ref.Value()
+			if w == nil {
+				return
+			}
+			w.bufferRuntimeStats()
+		case <-done:
+			return
+		}
+	}
+}
+
+// bufferRuntimeStats buffers a runtime statistics entry, unless a
+// Config.Transforms function dropped it
+func (w *Writer) bufferRuntimeStats() {
+	entry := w.buildRuntimeStatsEntry()
+	if entry.dropped {
+		w.stats.transformDropped.Add(1)
+		w.stats.dropped.Add(1)
+		return
+	}
+	_ = w.enqueue(entry)
+}
+
+// buildRuntimeStatsEntry captures goroutine, heap and GC statistics as an
+// info-level entry. It goes through buildLogEntry like any record, so
+// attribute rules and Transforms apply to it too. runtime.ReadMemStats
+// briefly stops the world, which is why the interval should be measured
+// in seconds rather than milliseconds.
+func (w *Writer) buildRuntimeStatsEntry() LogEntry {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	var lastPause uint64
+	if mem.NumGC > 0 {
+		lastPause = mem.PauseNs[(mem.NumGC+255)%256]
+	}
+
+	record := iris.NewRecord(iris.Info, "go runtime stats")
+	record.AddField(iris.Int("runtime.goroutines", runtime.NumGoroutine()))
+	record.AddField(iris.Uint64("runtime.heap_alloc_bytes", mem.HeapAlloc))
+	record.AddField(iris.Uint64("runtime.heap_objects", mem.HeapObjects))
+	record.AddField(iris.Uint64("runtime.gc_count", uint64(mem.NumGC)))
+	record.AddField(iris.Uint64("runtime.gc_pause_last_ns", lastPause))
+	record.AddField(iris.Uint64("runtime.gc_pause_total_ns", mem.PauseTotalNs))
+
+	tags := runtimeStatsTag
+	if ddtags := w.resolveTags(record); ddtags != "" {
+		tags = ddtags + "," + runtimeStatsTag
+	}
+	return w.buildLogEntryWithTags(record, tags)
+}
@@ -0,0 +1,121 @@
+// runtime_stats_test.go: Periodic Go runtime statistics tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_RuntimeStats(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:               "test-api-key",
+		Tags:                 map[string]string{"team": "core"},
+		BatchSize:            100,
+		FlushInterval:        time.Hour,
+		RuntimeStatsInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() {
+		writer.mutex.Lock()
+		writer.buffer = writer.buffer[:0]
+		writer.mutex.Unlock()
+		_ = writer.Close()
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	var entry LogEntry
+	for time.Now().Before(deadline) {
+		writer.mutex.Lock()
+		if len(writer.buffer) > 0 {
+			entry = writer.buffer[0]
+		}
+		writer.mutex.Unlock()
+		if entry.Message != "" {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if entry.Message != "go runtime stats" {
+		t.Fatal("Expected a runtime stats entry to be buffered")
+	}
+	if !strings.HasSuffix(entry.Tags, runtimeStatsTag) || !strings.HasPrefix(entry.Tags, "team:core") {
+		t.Errorf("Tags = %q, want static tags plus %q", entry.Tags, runtimeStatsTag)
+	}
+	if goroutines, ok := entry.Fields["runtime.goroutines"].(int64); !ok || goroutines <= 0 {
+		t.Errorf("runtime.goroutines = %v", entry.Fields["runtime.goroutines"])
+	}
+}
+
+func TestWriter_RuntimeStatsFollowsAttributeRules(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:    true,
+		Service:        "api",
+		ExcludeFields:  []string{"runtime.heap_objects"},
+		OmitAttributes: []string{"ddsource"},
+		Transforms: []func(*LogEntry){func(entry *LogEntry) {
+			entry.Fields["transformed"] = true
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	entry := writer.buildRuntimeStatsEntry()
+	if _, ok := entry.Fields["runtime.heap_objects"]; ok {
+		t.Error("runtime.heap_objects kept, want ExcludeFields applied")
+	}
+	if entry.Source != "" {
+		t.Errorf("Source = %q, want OmitAttributes applied", entry.Source)
+	}
+	if entry.Fields["transformed"] != true {
+		t.Error("Transforms not applied to the runtime stats entry")
+	}
+	if entry.Service != "api" || !strings.HasSuffix(entry.Tags, runtimeStatsTag) {
+		t.Errorf("entry = %+v, want service api and the %q tag", entry, runtimeStatsTag)
+	}
+}
+
+func TestWriter_RuntimeStatsCollectedWithoutClose(t *testing.T) {
+	errs := make(chan error, 1)
+	func() {
+		writer, err := New(Config{
+			CaptureMode:          true,
+			FlushInterval:        20 * time.Millisecond,
+			RuntimeStatsInterval: 10 * time.Millisecond,
+			OnError:              func(err error) { errs <- err },
+		})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, "forgotten"))
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrNotClosed) {
+				t.Fatalf("OnError(%v), want ErrNotClosed", err)
+			}
+			return
+		case <-deadline:
+			t.Fatal("abandoned writer was never collected; its runtime stats goroutine keeps it alive")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
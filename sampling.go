@@ -0,0 +1,27 @@
+// sampling.go: Level-aware sampling for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"github.com/agilira/iris"
+)
+
+// sampledOut reports whether a record at level should be discarded.
+// Records at or above Config.SampleBelowLevel are always kept; below it a
+// SampleRate fraction is kept, evenly spaced rather than random so the
+// outcome is reproducible. It runs after level filtering (the logger's
+// and a destination's MinLevel) and before service rejection.
+func (w *Writer) sampledOut(level iris.Level) bool {
+	rate := w.config.SampleRate
+	if rate <= 0 || rate >= 1 || level >= w.config.SampleBelowLevel {
+		return false
+	}
+
+	n := float64(w.sampleSeq.Add(1) - 1)
+	keep := int64((n+1)*rate) > int64(n*rate)
+	return !keep
+}
@@ -0,0 +1,58 @@
+// sampling_test.go: Level-aware sampling tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_SampleBelowLevel(t *testing.T) {
+	var out strings.Builder
+	writer, err := New(Config{
+		Output:           OutputStdout,
+		OutputWriter:     &out,
+		SampleBelowLevel: iris.Warn,
+		SampleRate:       0.25,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for i := 0; i < 100; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, "noise"))
+		_ = writer.WriteRecord(iris.NewRecord(iris.Debug, "noise"))
+	}
+	for i := 0; i < 10; i++ {
+		_ = writer.WriteRecord(iris.NewRecord(iris.Warn, "warning"))
+		_ = writer.WriteRecord(iris.NewRecord(iris.Error, "failure"))
+	}
+
+	stats := writer.Stats()
+	if stats.EntriesSampled != 150 {
+		t.Errorf("EntriesSampled = %d, want 150", stats.EntriesSampled)
+	}
+	if stats.EntriesSent != 70 {
+		t.Errorf("EntriesSent = %d, want 70", stats.EntriesSent)
+	}
+	if got := strings.Count(out.String(), `"status":"warn"`) + strings.Count(out.String(), `"status":"error"`); got != 20 {
+		t.Errorf("kept %d warnings and errors, want all 20", got)
+	}
+}
+
+func TestWriter_SamplingDisabled(t *testing.T) {
+	writer := &Writer{config: Config{SampleBelowLevel: iris.Error}}
+	for _, rate := range []float64{0, 1} {
+		writer.config.SampleRate = rate
+		if writer.sampledOut(iris.Debug) {
+			t.Errorf("SampleRate %v dropped a record, want sampling disabled", rate)
+		}
+	}
+}
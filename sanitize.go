@@ -0,0 +1,73 @@
+// sanitize.go: Single-line message sanitizing
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SanitizeMode selects how SanitizeMessages rewrites control characters
+type SanitizeMode int
+
+const (
+	// SanitizeEscape replaces control characters with escapes such as
+	// \n, \t or \u001b
+	SanitizeEscape SanitizeMode = iota
+
+	// SanitizeSpace replaces each control character with a space
+	SanitizeSpace
+)
+
+// originalMessageKey holds the unsanitized message with
+// Config.PreserveOriginalMessage
+const originalMessageKey = "original_message"
+
+// sanitizeMessage rewrites the control characters of a message so it fits
+// on a single line. Messages without control characters are returned as is.
+func sanitizeMessage(message string, mode SanitizeMode) string {
+	if strings.IndexFunc(message, unicode.IsControl) < 0 {
+		return message
+	}
+
+	var b strings.Builder
+	b.Grow(len(message) + 8)
+	for _, r := range message {
+		if !unicode.IsControl(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if mode == SanitizeSpace {
+			b.WriteByte(' ')
+			continue
+		}
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
+
+// applySanitize makes the entry message single-line per Config.SanitizeMessages
+func (w *Writer) applySanitize(entry *LogEntry) {
+	sanitized := sanitizeMessage(entry.Message, w.config.SanitizeMode)
+	if sanitized == entry.Message {
+		return
+	}
+	if w.config.PreserveOriginalMessage {
+		entry.Fields[originalMessageKey] = entry.Message
+	}
+	entry.Message = sanitized
+}
@@ -0,0 +1,53 @@
+// sanitize_test.go: Single-line message sanitizing tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestSanitizeMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		mode    SanitizeMode
+		want    string
+	}{
+		{"clean", "all good", SanitizeEscape, "all good"},
+		{"multi-line escape", "line one\nline two\r\n", SanitizeEscape, `line one\nline two\r\n`},
+		{"tab and escape char", "a\tb\x1b[31mred", SanitizeEscape, `a\tb\u001b[31mred`},
+		{"multi-line space", "line one\nline two", SanitizeSpace, "line one line two"},
+		{"control space", "a\x00b\x7fc", SanitizeSpace, "a b c"},
+		{"unicode kept", "café ☕\n", SanitizeEscape, `café ☕\n`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeMessage(tt.message, tt.mode); got != tt.want {
+				t.Errorf("sanitizeMessage(%q) = %q, want %q", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_SanitizeMessages(t *testing.T) {
+	writer := &Writer{config: Config{SanitizeMessages: true, PreserveOriginalMessage: true}}
+
+	entry := writer.buildLogEntry(iris.NewRecord(iris.Error, "panic: boom\ngoroutine 1"))
+	if entry.Message != `panic: boom\ngoroutine 1` {
+		t.Errorf("Message = %q, want a single line", entry.Message)
+	}
+	if entry.Fields[originalMessageKey] != "panic: boom\ngoroutine 1" {
+		t.Errorf("original_message = %v, want the raw message", entry.Fields[originalMessageKey])
+	}
+
+	entry = writer.buildLogEntry(iris.NewRecord(iris.Info, "single line"))
+	if _, ok := entry.Fields[originalMessageKey]; ok {
+		t.Error("original_message must only be set when the message changed")
+	}
+}
@@ -0,0 +1,70 @@
+// serializer.go: Pluggable encoding of intake request bodies
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Content types of the built-in serializers
+const (
+	contentTypeJSON   = "application/json"
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+// Serializer encodes a batch into a request body. Implementations must be
+// safe for concurrent use, since batches may be sent concurrently. Entries
+// marshal to Datadog's log format through LogEntry.MarshalJSON, which
+// third-party JSON libraries honor.
+type Serializer interface {
+	Serialize(entries []LogEntry) (body []byte, contentType string, err error)
+}
+
+// JSONSerializer encodes a batch as a JSON array, the writer's default
+type JSONSerializer struct{}
+
+// Serialize implements Serializer
+func (JSONSerializer) Serialize(entries []LogEntry) ([]byte, string, error) {
+	body, err := json.Marshal(entries)
+	return body, contentTypeJSON, err
+}
+
+// NDJSONSerializer encodes a batch as newline-delimited JSON, one entry
+// per line
+type NDJSONSerializer struct{}
+
+// Serialize implements Serializer
+func (NDJSONSerializer) Serialize(entries []LogEntry) ([]byte, string, error) {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := entry.MarshalJSON()
+		if err != nil {
+			return nil, "", err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), contentTypeNDJSON, nil
+}
+
+// serialize encodes a batch with Config.Serializer, JSONSerializer by
+// default, after applying Config.MinimalPayload and Config.EmitEntryChecksum
+func (w *Writer) serialize(entries []LogEntry) ([]byte, string, error) {
+	if w.config.MinimalPayload {
+		entries = w.minimalEntries(entries)
+	}
+	if w.config.EmitEntryChecksum {
+		if err := w.checksumEntries(entries); err != nil {
+			return nil, "", err
+		}
+	}
+	if w.config.Serializer != nil {
+		return w.config.Serializer.Serialize(entries)
+	}
+	return JSONSerializer{}.Serialize(entries)
+}
@@ -0,0 +1,94 @@
+// serializer_test.go: Request body serializer tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestNDJSONSerializer(t *testing.T) {
+	entries := []LogEntry{
+		{Timestamp: 1, Level: "info", Message: "first", Fields: map[string]any{"user": "ada"}},
+		{Timestamp: 2, Level: "warn", Message: "second"},
+	}
+	body, contentType, err := NDJSONSerializer{}.Serialize(entries)
+	if err != nil {
+		t.Fatalf("Serialize() error = %v", err)
+	}
+	if contentType != "application/x-ndjson" {
+		t.Errorf("content type = %q", contentType)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("body = %q, want 2 lines", body)
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first["message"] != "first" || first["user"] != "ada" {
+		t.Errorf("line 0 = %s (%v)", lines[0], err)
+	}
+}
+
+// upperSerializer is a custom serializer that shouts every message
+type upperSerializer struct{}
+
+func (upperSerializer) Serialize(entries []LogEntry) ([]byte, string, error) {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(strings.ToUpper(entry.Message))
+		b.WriteByte('\n')
+	}
+	return []byte(b.String()), "text/plain", nil
+}
+
+type failingSerializer struct{}
+
+func (failingSerializer) Serialize([]LogEntry) ([]byte, string, error) {
+	return nil, "", errors.New("encoder exploded")
+}
+
+func TestWriter_Serializer(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		contentType, body = r.Header.Get("Content-Type"), string(data)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:     "test-api-key",
+		Site:       strings.TrimPrefix(server.URL, "http://"),
+		Serializer: upperSerializer{},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "plugged in"))
+	_ = writer.Close()
+	if contentType != "text/plain" || body != "PLUGGED IN\n" {
+		t.Errorf("request = %q (%s), want the custom encoding", body, contentType)
+	}
+
+	var errs []error
+	writer, err = New(Config{CaptureMode: true, Serializer: failingSerializer{}, OnError: func(err error) { errs = append(errs, err) }})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "lost"))
+	_ = writer.Close()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "encoder exploded") || writer.Stats().EntriesDropped != 1 {
+		t.Errorf("errors = %v, dropped = %d; want the serializer failure reported", errs, writer.Stats().EntriesDropped)
+	}
+}
@@ -0,0 +1,73 @@
+// service.go: Per-record service resolution for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"fmt"
+
+	"github.com/agilira/iris"
+)
+
+// ServicePolicy selects what happens to records whose service is not in
+// Config.AllowedServices
+type ServicePolicy int
+
+const (
+	// ServiceRemap replaces a disallowed service with Config.Service
+	ServiceRemap ServicePolicy = iota
+
+	// ServiceReject drops records with a disallowed service
+	ServiceReject
+)
+
+// recordService returns the service a record asks for: the string value
+// of Config.ServiceField when present, otherwise Config.Service
+func (w *Writer) recordService(record *iris.Record) string {
+	if w.config.ServiceField != "" {
+		if value, ok := lookupString(record, w.config.ServiceField); ok && value != "" {
+			return value
+		}
+	}
+	return w.config.Service
+}
+
+// resolveService returns the entry service, remapping services outside
+// Config.AllowedServices to Config.Service, then renamed through
+// Config.ServiceMapping
+func (w *Writer) resolveService(record *iris.Record) string {
+	service := w.recordService(record)
+	if w.allowedServices != nil && w.config.ServicePolicy == ServiceRemap && !w.serviceAllowed(service) {
+		w.stats.servicesRemapped.Add(1)
+		service = w.config.Service
+	}
+	if mapped, ok := w.config.ServiceMapping[service]; ok {
+		return mapped
+	}
+	return service
+}
+
+// rejectService reports whether the record must be dropped under
+// ServiceReject
+func (w *Writer) rejectService(record *iris.Record) bool {
+	if w.allowedServices == nil || w.config.ServicePolicy != ServiceReject {
+		return false
+	}
+	return !w.serviceAllowed(w.recordService(record))
+}
+
+// serviceAllowed checks service against Config.AllowedServices. The
+// writer's own Config.Service is always allowed; each disallowed service
+// is reported to OnError the first time any partition sees it.
+func (w *Writer) serviceAllowed(service string) bool {
+	if service == w.config.Service || w.allowedServices[service] {
+		return true
+	}
+	if _, seen := w.root().disallowedSeen.LoadOrStore(service, struct{}{}); !seen {
+		w.handleError(fmt.Errorf("service %q is not in AllowedServices", service))
+	}
+	return false
+}
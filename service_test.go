@@ -0,0 +1,115 @@
+// service_test.go: Per-record service resolution tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func serviceRecord(service string) *iris.Record {
+	record := iris.NewRecord(iris.Info, "request")
+	record.AddField(iris.Str("svc", service))
+	return record
+}
+
+func TestWriter_AllowedServicesRemap(t *testing.T) {
+	var out strings.Builder
+	var reported []error
+	writer, err := New(Config{
+		Output:          OutputStdout,
+		OutputWriter:    &out,
+		Service:         "gateway",
+		ServiceField:    "svc",
+		AllowedServices: []string{"billing"},
+		OnError:         func(err error) { reported = append(reported, err) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(serviceRecord("billing"))
+	_ = writer.WriteRecord(serviceRecord("rogue"))
+	_ = writer.WriteRecord(serviceRecord("rogue"))
+
+	if got := strings.Count(out.String(), `"service":"billing"`); got != 1 {
+		t.Errorf("billing entries = %d, want 1", got)
+	}
+	if got := strings.Count(out.String(), `"service":"gateway"`); got != 2 {
+		t.Errorf("remapped entries = %d, want 2", got)
+	}
+	if stats := writer.Stats(); stats.ServicesRemapped != 2 {
+		t.Errorf("ServicesRemapped = %d, want 2", stats.ServicesRemapped)
+	}
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), `"rogue"`) {
+		t.Errorf("OnError calls = %v, want one report for rogue", reported)
+	}
+}
+
+func TestWriter_AllowedServicesReject(t *testing.T) {
+	var out strings.Builder
+	writer, err := New(Config{
+		Output:          OutputStdout,
+		OutputWriter:    &out,
+		Service:         "gateway",
+		ServiceField:    "svc",
+		AllowedServices: []string{"billing"},
+		ServicePolicy:   ServiceReject,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(serviceRecord("billing"))
+	_ = writer.WriteRecord(serviceRecord("rogue"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "own service"))
+
+	stats := writer.Stats()
+	if stats.ServicesRejected != 1 || stats.EntriesDropped != 1 {
+		t.Errorf("ServicesRejected = %d, EntriesDropped = %d, want 1 and 1", stats.ServicesRejected, stats.EntriesDropped)
+	}
+	if strings.Contains(out.String(), "rogue") {
+		t.Errorf("rejected entry was written: %s", out.String())
+	}
+	if stats.EntriesSent != 2 {
+		t.Errorf("EntriesSent = %d, want 2", stats.EntriesSent)
+	}
+}
+
+func TestWriter_ServiceMapping(t *testing.T) {
+	writer, err := New(Config{
+		APIKey:       "test-api-key",
+		Service:      "legacy-gateway",
+		ServiceField: "svc",
+		ServiceMapping: map[string]string{
+			"legacy-gateway": "api-gateway",
+			"billing_v1":     "billing",
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	tests := []struct {
+		record *iris.Record
+		want   string
+	}{
+		{iris.NewRecord(iris.Info, "default service"), "api-gateway"},
+		{serviceRecord("billing_v1"), "billing"},
+		{serviceRecord("payments"), "payments"},
+	}
+	for _, tt := range tests {
+		if got := writer.buildLogEntry(tt.record).Service; got != tt.want {
+			t.Errorf("service = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// split.go: Sub-batch splitting and parallel delivery for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"sync"
+)
+
+// maxEntriesPerRequest is the Datadog intake limit on entries per request
+const maxEntriesPerRequest = 1000
+
+// defaultMaxSplitConcurrency bounds the sub-batches of one flush in flight
+const defaultMaxSplitConcurrency = 4
+
+// splitBatch cuts entries into sub-batches the intake accepts
+func splitBatch(entries []LogEntry) [][]LogEntry {
+	batches := make([][]LogEntry, 0, (len(entries)+maxEntriesPerRequest-1)/maxEntriesPerRequest)
+	for len(entries) > maxEntriesPerRequest {
+		batches = append(batches, entries[:maxEntriesPerRequest:maxEntriesPerRequest])
+		entries = entries[maxEntriesPerRequest:]
+	}
+	return append(batches, entries)
+}
+
+// sendBatches sends the sub-batches of a flush, up to
+// Config.MaxSplitConcurrency at a time, and joins their errors. A
+// sub-batch that fails is reported to Config.OnDropBatch on its own.
+func (w *Writer) sendBatches(batches [][]LogEntry, correlationID string) error {
+	if len(batches) == 1 {
+		return w.sendBatch(batches[0], correlationID)
+	}
+
+	errs := make([]error, len(batches))
+	sem := make(chan struct{}, w.config.MaxSplitConcurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, batch []LogEntry) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = w.sendBatch(batch, correlationID)
+		}(i, batch)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// sendBatch sends one request's worth of entries, reporting them to
+// Config.OnDropBatch if they could not be delivered. After Close, batches
+// that failed with a retryable error are retried in the background when
+// Config.BackgroundCloseRetry is set, and reported only once that gives
+// up. The retry works on a copy: with Config.DoubleBuffer the flush
+// clears and reuses entries as soon as it returns.
+func (w *Writer) sendBatch(entries []LogEntry, correlationID string) error {
+	background := w.config.BackgroundCloseRetry > 0 && w.closed.Load()
+	err := w.sendToDatadog(entries, correlationID, !background)
+	if err != nil && background {
+		if !isPermanent(err) {
+			go w.retryAfterClose(copyEntries(entries), correlationID, err)
+			return err
+		}
+		w.failBatch(entries, err)
+	}
+	if err != nil && w.config.OnDropBatch != nil {
+		w.config.OnDropBatch(entries, err)
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+// split_test.go: Sub-batch splitting and parallel delivery tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestSplitBatch(t *testing.T) {
+	tests := []struct {
+		entries int
+		want    []int
+	}{
+		{1, []int{1}},
+		{1000, []int{1000}},
+		{1001, []int{1000, 1}},
+		{2500, []int{1000, 1000, 500}},
+	}
+	for _, tt := range tests {
+		batches := splitBatch(make([]LogEntry, tt.entries))
+		var sizes []int
+		for _, batch := range batches {
+			sizes = append(sizes, len(batch))
+		}
+		if fmt.Sprint(sizes) != fmt.Sprint(tt.want) {
+			t.Errorf("splitBatch(%d) sizes = %v, want %v", tt.entries, sizes, tt.want)
+		}
+	}
+}
+
+func TestWriter_SplitParallelSend(t *testing.T) {
+	var active, peak, received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		var logs []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&logs)
+		time.Sleep(20 * time.Millisecond)
+		if len(logs) > maxEntriesPerRequest {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if logs[0]["message"] == "poison" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received.Add(int64(len(logs)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var dropped [][]LogEntry
+	writer, err := New(Config{
+		APIKey:              "test-api-key",
+		Site:                strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:           4500,
+		FlushInterval:       time.Hour,
+		MaxSplitConcurrency: 2,
+		OnDropBatch: func(entries []LogEntry, err error) {
+			mu.Lock()
+			dropped = append(dropped, entries)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	for i := 0; i < 4500; i++ {
+		msg := "entry"
+		if i == 2000 {
+			msg = "poison"
+		}
+		_ = writer.WriteRecord(iris.NewRecord(iris.Info, msg))
+	}
+
+	if got := received.Load(); got != 3500 {
+		t.Errorf("received = %d, want 3500", got)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrent requests = %d, want MaxSplitConcurrency 2", got)
+	}
+	if len(dropped) != 1 || len(dropped[0]) != 1000 || dropped[0][0].Message != "poison" {
+		t.Errorf("OnDropBatch received %d batches, want only the failed sub-batch", len(dropped))
+	}
+}
+
+func benchmarkSplitFlush(b *testing.B, concurrency int) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	writer, err := New(Config{
+		APIKey:              "test-api-key",
+		Site:                strings.TrimPrefix(server.URL, "http://"),
+		BatchSize:           5000,
+		FlushInterval:       time.Hour,
+		MaxSplitConcurrency: concurrency,
+	})
+	if err != nil {
+		b.Fatalf("Failed to create writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	entries := make([]LogEntry, 5000)
+	for i := range entries {
+		entries[i] = LogEntry{Level: "info", Message: "benchmark"}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = writer.deliver(entries)
+	}
+}
+
+func BenchmarkFlush5000_Sequential(b *testing.B) { benchmarkSplitFlush(b, 1) }
+func BenchmarkFlush5000_Parallel(b *testing.B)   { benchmarkSplitFlush(b, 5) }
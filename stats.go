@@ -0,0 +1,296 @@
+// stats.go: Delivery statistics for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time snapshot of the writer's delivery counters
+type Stats struct {
+	// EntriesSent is the number of entries accepted by Datadog
+	EntriesSent uint64
+
+	// EntriesDropped is the number of entries discarded without delivery
+	EntriesDropped uint64
+
+	// EntriesDroppedMemory is the number of entries dropped because the
+	// buffer reached Config.MaxBufferBytes (also counted in EntriesDropped)
+	EntriesDroppedMemory uint64
+
+	// BufferedBytes is the estimated size of the entries currently buffered
+	BufferedBytes int
+
+	// DeliveryLag is how long ago the oldest buffered or in-flight entry
+	// was generated (millisecond resolution, zero when nothing is pending).
+	// A rising lag warns that delivery is degrading before drops occur.
+	DeliveryLag time.Duration
+
+	// ServicesRemapped is the number of entries whose disallowed service
+	// was replaced by Config.Service
+	ServicesRemapped uint64
+
+	// ServicesRejected is the number of records dropped for a disallowed
+	// service (also counted in EntriesDropped)
+	ServicesRejected uint64
+
+	// EntriesRecoveredAfterClose is the number of entries delivered by
+	// BackgroundCloseRetry after Close returned. Batches it gives up on
+	// are counted in EntriesDropped once.
+	EntriesRecoveredAfterClose uint64
+
+	// FlushesCoalesced is the number of size-triggered flushes held back
+	// by Config.MinFlushInterval
+	FlushesCoalesced uint64
+
+	// RateLimit is the latest rate-limit state from the intake headers,
+	// zero until Config.RespectRateLimitHeaders sees one
+	RateLimit RateLimit
+
+	// RateLimitThrottles is the number of times sends were paused because
+	// few requests remained in the rate-limit period
+	RateLimitThrottles uint64
+
+	// EntriesTransformDropped is the number of entries discarded by a
+	// Config.Transforms function (also counted in EntriesDropped)
+	EntriesTransformDropped uint64
+
+	// Partitions is the number of Config.PartitionField partitions created
+	Partitions int
+
+	// PartitionOverflow is the number of records sent to the default
+	// buffer because Config.MaxPartitions was reached
+	PartitionOverflow uint64
+
+	// EventsSent and EventsFailed count Events API posts made for
+	// Config.EmitEventsAboveLevel; EventsDropped counts events discarded
+	// because the event queue was full
+	EventsSent    uint64
+	EventsFailed  uint64
+	EventsDropped uint64
+
+	// SyslogDropped is the number of records dropped without a write
+	// because the syslog connection was down (also in EntriesDropped)
+	SyslogDropped uint64
+
+	// InvalidClientIPs is the number of Config.ClientIPField values that
+	// were not IP addresses and so were not sent as network.client.ip
+	InvalidClientIPs uint64
+
+	// WALReplayed is the number of entries re-sent from a previous
+	// process's write-ahead log
+	WALReplayed uint64
+
+	// WALLost is the number of unconfirmed entries discarded from the
+	// write-ahead log by Config.WALMaxBytes
+	WALLost uint64
+
+	// FlushesSkipped is the number of flush triggers dropped because a
+	// concurrent flush had just sent the buffer
+	FlushesSkipped uint64
+
+	// EntriesSampled is the number of records discarded by sampling (see
+	// Config.SampleRate). They are not counted in EntriesDropped.
+	EntriesSampled uint64
+
+	// EntriesExpired is the number of entries dropped for being older than
+	// Config.MaxLogAge (also counted in EntriesDropped)
+	EntriesExpired uint64
+
+	// EntriesCoalesced is the number of duplicate entries folded into a
+	// previous entry's "dd.repeat_count"
+	EntriesCoalesced uint64
+
+	// EntriesJoined is the number of continuation records appended to the
+	// previous entry's message (see Config.JoinContinuations)
+	EntriesJoined uint64
+
+	// EntriesInvalid is the number of records rejected by Config.StrictMode
+	// (also counted in EntriesDropped)
+	EntriesInvalid uint64
+
+	// EntriesTruncated is the number of entries whose message was cut to
+	// Config.MaxMessageBytes
+	EntriesTruncated uint64
+
+	// FieldsTruncated is the number of attribute values cut to
+	// Config.MaxFieldValueBytes
+	FieldsTruncated uint64
+
+	// Requests is the number of HTTP requests issued, including retries
+	Requests uint64
+
+	// NewConnections is the number of intake requests that could not reuse
+	// an idle connection; it should stay far below Requests
+	NewConnections uint64
+
+	// DNSFastFails is the number of batches failed without a request
+	// because the DNS circuit was open (see Config.DNSFailureThreshold)
+	DNSFastFails uint64
+
+	// FailedRequests is the number of HTTP requests that did not succeed
+	FailedRequests uint64
+
+	// ConsecutiveFailures is the number of batches that failed in a row
+	ConsecutiveFailures uint64
+
+	// RetriesDenied is the number of retries skipped because the retry budget was exhausted
+	RetriesDenied uint64
+
+	// LifetimeRequestsRemaining is the number of requests left under
+	// Config.MaxLifetimeRequests (zero when no cap is configured)
+	LifetimeRequestsRemaining uint64
+
+	// RetryBudget is the number of retry tokens currently available
+	// (zero when no retry budget is configured)
+	RetryBudget float64
+
+	// CompressionSkipped is the number of batches sent uncompressed because
+	// too many sends were in flight (see Config.CompressionMaxInFlight)
+	CompressionSkipped uint64
+
+	// CompressionFallbacks is the number of batches sent uncompressed
+	// because compressing them failed
+	CompressionFallbacks uint64
+
+	// ActiveRequests is the number of intake requests currently in progress
+	ActiveRequests int64
+
+	// LastRequestID is the most recent Datadog request ID seen in a response
+	LastRequestID string
+
+	// InCooldown reports whether sends are paused by a 429 Retry-After response
+	InCooldown bool
+
+	// Disabled reports whether the writer disabled itself after repeated failures
+	Disabled bool
+}
+
+// writerStats holds the live counters behind Stats
+type writerStats struct {
+	sent                 atomic.Uint64
+	dropped              atomic.Uint64
+	memoryDropped        atomic.Uint64
+	servicesRemapped     atomic.Uint64
+	servicesRejected     atomic.Uint64
+	recoveredAfterClose  atomic.Uint64
+	flushesCoalesced     atomic.Uint64
+	rateLimitThrottles   atomic.Uint64
+	transformDropped     atomic.Uint64
+	partitionOverflow    atomic.Uint64
+	eventsSent           atomic.Uint64
+	eventsFailed         atomic.Uint64
+	eventsDropped        atomic.Uint64
+	syslogDropped        atomic.Uint64
+	invalidClientIPs     atomic.Uint64
+	walReplayed          atomic.Uint64
+	flushesSkipped       atomic.Uint64
+	sampled              atomic.Uint64
+	expired              atomic.Uint64
+	coalesced            atomic.Uint64
+	joined               atomic.Uint64
+	invalid              atomic.Uint64
+	truncated            atomic.Uint64
+	fieldsTruncated      atomic.Uint64
+	requests             atomic.Uint64
+	newConnections       atomic.Uint64
+	dnsFastFails         atomic.Uint64
+	failedRequests       atomic.Uint64
+	consecutiveFailures  atomic.Uint64
+	retriesDenied        atomic.Uint64
+	compressionSkipped   atomic.Uint64
+	compressionFallbacks atomic.Uint64
+}
+
+// Stats returns a snapshot of the writer's delivery counters. With
+// Config.PartitionField the counters are summed over all partitions.
+func (w *Writer) Stats() Stats {
+	stats := w.ownStats()
+	if w.config.PartitionField != "" {
+		partitions := w.partitionList()
+		stats.Partitions = len(partitions)
+		lag := stats.DeliveryLag
+		// Shared with the partitions, so not summed
+		remaining := stats.LifetimeRequestsRemaining
+		for _, partition := range partitions {
+			own := partition.ownStats()
+			lag = max(lag, own.DeliveryLag)
+			stats.addCounters(own)
+		}
+		stats.DeliveryLag = lag
+		stats.LifetimeRequestsRemaining = remaining
+	}
+	return stats
+}
+
+// ownStats returns the counters of this writer alone
+func (w *Writer) ownStats() Stats {
+	var lifetimeRemaining uint64
+	if limit := uint64(max(w.config.MaxLifetimeRequests, 0)); limit > w.stats.requests.Load() {
+		lifetimeRemaining = limit - w.stats.requests.Load()
+	}
+	var rateLimit RateLimit
+	if snapshot := w.rateLimit.Load(); snapshot != nil {
+		rateLimit = *snapshot
+	}
+	var budget float64
+	if w.budget != nil {
+		budget = w.budget.available()
+	}
+
+	lastRequestID, _ := w.lastRequestID.Load().(string)
+
+	w.mutex.Lock()
+	bufferedBytes := w.bufferBytes
+	w.mutex.Unlock()
+
+	return Stats{
+		EntriesSent:                w.stats.sent.Load(),
+		EntriesDropped:             w.stats.dropped.Load(),
+		EntriesDroppedMemory:       w.stats.memoryDropped.Load(),
+		BufferedBytes:              bufferedBytes,
+		DeliveryLag:                w.deliveryLag(time.Now()),
+		ServicesRemapped:           w.stats.servicesRemapped.Load(),
+		ServicesRejected:           w.stats.servicesRejected.Load(),
+		EntriesRecoveredAfterClose: w.stats.recoveredAfterClose.Load(),
+		FlushesCoalesced:           w.stats.flushesCoalesced.Load(),
+		RateLimit:                  rateLimit,
+		RateLimitThrottles:         w.stats.rateLimitThrottles.Load(),
+		EntriesTransformDropped:    w.stats.transformDropped.Load(),
+		PartitionOverflow:          w.stats.partitionOverflow.Load(),
+		EventsSent:                 w.stats.eventsSent.Load(),
+		EventsFailed:               w.stats.eventsFailed.Load(),
+		EventsDropped:              w.stats.eventsDropped.Load(),
+		SyslogDropped:              w.stats.syslogDropped.Load(),
+		InvalidClientIPs:           w.stats.invalidClientIPs.Load(),
+		WALReplayed:                w.stats.walReplayed.Load(),
+		WALLost:                    w.wal.lostEntries(),
+		FlushesSkipped:             w.stats.flushesSkipped.Load(),
+		EntriesSampled:             w.stats.sampled.Load(),
+		EntriesExpired:             w.stats.expired.Load(),
+		EntriesCoalesced:           w.stats.coalesced.Load(),
+		EntriesJoined:              w.stats.joined.Load(),
+		EntriesInvalid:             w.stats.invalid.Load(),
+		EntriesTruncated:           w.stats.truncated.Load(),
+		FieldsTruncated:            w.stats.fieldsTruncated.Load(),
+		Requests:                   w.stats.requests.Load(),
+		NewConnections:             w.stats.newConnections.Load(),
+		DNSFastFails:               w.stats.dnsFastFails.Load(),
+		FailedRequests:             w.stats.failedRequests.Load(),
+		ConsecutiveFailures:        w.stats.consecutiveFailures.Load(),
+		RetriesDenied:              w.stats.retriesDenied.Load(),
+		LifetimeRequestsRemaining:  lifetimeRemaining,
+		RetryBudget:                budget,
+		CompressionSkipped:         w.stats.compressionSkipped.Load(),
+		CompressionFallbacks:       w.stats.compressionFallbacks.Load(),
+		ActiveRequests:             w.active.Load(),
+		LastRequestID:              lastRequestID,
+		InCooldown:                 w.inCooldown(),
+		Disabled:                   w.disabled.Load(),
+	}
+}
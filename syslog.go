@@ -0,0 +1,241 @@
+// syslog.go: RFC 5424 syslog output for the Datadog writer
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// syslogFacility is the syslog facility used for all messages (user-level)
+	syslogFacility = 1
+
+	// syslogMaxBackoff caps the delay between background reconnects
+	syslogMaxBackoff = 30 * time.Second
+)
+
+// ErrSyslogDisconnected is returned by WriteRecord in the syslog output
+// modes while the connection is down and being re-established
+var ErrSyslogDisconnected = errors.New("syslog connection is down")
+
+// syslogSeverities maps Datadog statuses to syslog severities
+var syslogSeverities = map[string]int{
+	"emergency": 0,
+	"alert":     1,
+	"critical":  2,
+	"error":     3,
+	"warn":      4,
+	"notice":    5,
+	"info":      6,
+	"debug":     7,
+}
+
+// sdEscaper escapes SD-PARAM values as required by RFC 5424
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// isSyslog reports whether the output mode streams to a syslog socket
+func (m OutputMode) isSyslog() bool {
+	return m == OutputSyslogTCP || m == OutputSyslogUDP
+}
+
+// network returns the socket network for a syslog output mode
+func (m OutputMode) network() string {
+	if m == OutputSyslogUDP {
+		return "udp"
+	}
+	return "tcp"
+}
+
+// formatSyslog renders entry as an RFC 5424 message. Datadog's fixed
+// attributes go in a "metas" structured-data element, the convention used
+// by the Agent's syslog intake, and the message body is the JSON entry so
+// custom attributes survive.
+func formatSyslog(entry LogEntry) ([]byte, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	severity, ok := syslogSeverities[entry.Level]
+	if !ok {
+		severity = syslogSeverities["info"]
+	}
+
+	var b strings.Builder
+	b.Grow(len(body) + 128)
+	b.WriteByte('<')
+	b.WriteString(strconv.Itoa(syslogFacility*8 + severity))
+	b.WriteString(">1 ")
+	b.WriteString(time.UnixMilli(entry.Timestamp).UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(entry.Hostname))
+	b.WriteByte(' ')
+	b.WriteString(syslogHeaderField(entry.Service))
+	b.WriteByte(' ')
+	b.WriteString(strconv.Itoa(os.Getpid()))
+	b.WriteString(" - [metas")
+	writeSDParam(&b, "ddsource", entry.Source)
+	writeSDParam(&b, "ddtags", entry.Tags)
+	writeSDParam(&b, "service", entry.Service)
+	b.WriteString("] ")
+	b.Write(body)
+	return []byte(b.String()), nil
+}
+
+// syslogHeaderField returns value with spaces removed, or the NILVALUE
+func syslogHeaderField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(value, " ", "_")
+}
+
+func writeSDParam(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	b.WriteByte(' ')
+	b.WriteString(name)
+	b.WriteString(`="`)
+	_, _ = sdEscaper.WriteString(b, value)
+	b.WriteByte('"')
+}
+
+// writeSyslog streams a single entry to Config.SyslogAddress. TCP messages
+// use octet-counting framing (RFC 6587). Dialing never happens here: a
+// broken connection is re-established in the background, and entries
+// written meanwhile are dropped at once and counted in SyslogDropped.
+func (w *Writer) writeSyslog(entry LogEntry) error {
+	if w.config.EmitEntryChecksum {
+		if err := w.stampChecksum(&entry); err != nil {
+			w.handleError(err)
+			w.stats.dropped.Add(1)
+			return err
+		}
+	}
+	msg, err := formatSyslog(entry)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal log entry: %w", err)
+		w.handleError(err)
+		w.stats.dropped.Add(1)
+		return err
+	}
+	if w.config.Output == OutputSyslogTCP {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	w.outputMutex.Lock()
+	if w.syslogConn == nil {
+		w.reconnectSyslog()
+		w.outputMutex.Unlock()
+		w.stats.syslogDropped.Add(1)
+		w.stats.dropped.Add(1)
+		return ErrSyslogDisconnected
+	}
+	err = w.sendSyslog(msg)
+	if err != nil {
+		w.closeSyslog()
+		w.reconnectSyslog()
+	}
+	w.outputMutex.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to write syslog message: %w", err)
+		w.handleError(err)
+		w.stats.dropped.Add(1)
+		return err
+	}
+	w.stats.sent.Add(1)
+	return nil
+}
+
+// sendSyslog writes msg to the open connection. Must be called with
+// outputMutex held.
+func (w *Writer) sendSyslog(msg []byte) error {
+	_ = w.syslogConn.SetWriteDeadline(time.Now().Add(w.config.Timeout))
+	_, err := w.syslogConn.Write(msg)
+	return err
+}
+
+// closeSyslog closes the syslog connection. Must be called with
+// outputMutex held.
+func (w *Writer) closeSyslog() {
+	if w.syslogConn != nil {
+		_ = w.syslogConn.Close()
+		w.syslogConn = nil
+	}
+}
+
+// dialSyslog opens a connection to Config.SyslogAddress
+func (w *Writer) dialSyslog() (net.Conn, error) {
+	return net.DialTimeout(w.config.Output.network(), w.config.SyslogAddress, w.config.Timeout)
+}
+
+// connectSyslog dials once at startup. When the listener is not up yet
+// the failure is reported and the connection is made in the background.
+func (w *Writer) connectSyslog() {
+	conn, err := w.dialSyslog()
+	if err != nil {
+		w.handleError(fmt.Errorf("failed to connect to syslog: %w", err))
+		w.outputMutex.Lock()
+		w.reconnectSyslog()
+		w.outputMutex.Unlock()
+		return
+	}
+	w.syslogConn = conn
+}
+
+// reconnectSyslog starts the background reconnect unless one is already
+// running. Must be called with outputMutex held.
+func (w *Writer) reconnectSyslog() {
+	if w.syslogRedial || w.closed.Load() {
+		return
+	}
+	w.syslogRedial = true
+	go w.runSyslogReconnect()
+}
+
+// runSyslogReconnect dials with exponential backoff, from RetryDelay up
+// to syslogMaxBackoff, until a connection is made or the writer closes
+func (w *Writer) runSyslogReconnect() {
+	delay := w.config.RetryDelay
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.done:
+			w.outputMutex.Lock()
+			w.syslogRedial = false
+			w.outputMutex.Unlock()
+			return
+		case <-timer.C:
+		}
+
+		conn, err := w.dialSyslog()
+		if err != nil {
+			delay = min(2*delay, syslogMaxBackoff)
+			timer.Reset(delay)
+			continue
+		}
+		w.outputMutex.Lock()
+		w.syslogRedial = false
+		if w.closed.Load() {
+			_ = conn.Close()
+		} else {
+			w.syslogConn = conn
+		}
+		w.outputMutex.Unlock()
+		return
+	}
+}
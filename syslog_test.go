@@ -0,0 +1,207 @@
+// syslog_test.go: RFC 5424 syslog output tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func TestFormatSyslog(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli(),
+		Level:     "error",
+		Message:   "disk full",
+		Service:   "api",
+		Source:    "go",
+		Tags:      `env:prod,note:a"b]`,
+		Hostname:  "web 01",
+	}
+
+	msg, err := formatSyslog(entry)
+	if err != nil {
+		t.Fatalf("formatSyslog() error = %v", err)
+	}
+
+	want := fmt.Sprintf(`<11>1 2025-03-01T12:00:00.000Z web_01 api %d - [metas ddsource="go" ddtags="env:prod,note:a\"b\]" service="api"] {`, os.Getpid())
+	if !strings.HasPrefix(string(msg), want) {
+		t.Errorf("formatSyslog() = %q, want prefix %q", msg, want)
+	}
+	if !strings.HasSuffix(string(msg), `"message":"disk full","service":"api","ddsource":"go","ddtags":"env:prod,note:a\"b]","hostname":"web 01"}`) {
+		t.Errorf("formatSyslog() body = %q, want JSON entry", msg)
+	}
+}
+
+func TestWriter_OutputSyslogTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	writer, err := New(Config{
+		Output:        OutputSyslogTCP,
+		SyslogAddress: listener.Addr().String(),
+		Service:       "syslog-test",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Warn, "first"))
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "second"))
+
+	for _, want := range []string{`<12>1 `, `<14>1 `} {
+		select {
+		case msg := <-received:
+			if !strings.HasPrefix(msg, want) || !strings.Contains(msg, "syslog-test") {
+				t.Errorf("message = %q, want prefix %q", msg, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for syslog message")
+		}
+	}
+	if got := writer.Stats().EntriesSent; got != 2 {
+		t.Errorf("EntriesSent = %d, want 2", got)
+	}
+}
+
+func TestWriter_OutputSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	writer, err := New(Config{
+		Output:        OutputSyslogUDP,
+		SyslogAddress: conn.LocalAddr().String(),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_ = writer.WriteRecord(iris.NewRecord(iris.Error, "datagram"))
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<11>1 ") || !strings.Contains(msg, `"message":"datagram"`) {
+		t.Errorf("datagram = %q, want unframed RFC 5424 message", msg)
+	}
+}
+
+func TestNew_SyslogRequiresAddress(t *testing.T) {
+	if _, err := New(Config{Output: OutputSyslogTCP}); err == nil {
+		t.Error("Expected error without SyslogAddress")
+	}
+}
+
+func TestWriter_SyslogReconnectsInBackground(t *testing.T) {
+	// Reserve a free port, then leave it closed so the first dial fails
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	address := probe.Addr().String()
+	_ = probe.Close()
+
+	writer, err := New(Config{
+		Output:        OutputSyslogTCP,
+		SyslogAddress: address,
+		Timeout:       5 * time.Second,
+		RetryDelay:    10 * time.Millisecond,
+		OnError:       func(error) {},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if err := writer.WriteRecord(iris.NewRecord(iris.Info, "lost")); !errors.Is(err, ErrSyslogDisconnected) {
+			t.Fatalf("WriteRecord() error = %v, want ErrSyslogDisconnected", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("100 writes while disconnected took %v, want them to fail fast", elapsed)
+	}
+	if stats := writer.Stats(); stats.SyslogDropped != 100 || stats.EntriesDropped != 100 {
+		t.Errorf("SyslogDropped = %d, EntriesDropped = %d, want 100", stats.SyslogDropped, stats.EntriesDropped)
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", address, err)
+	}
+	defer func() { _ = listener.Close() }()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		line, _ := bufio.NewReader(conn).ReadString(']')
+		received <- line
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for writer.WriteRecord(iris.NewRecord(iris.Info, "back")) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the background reconnect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case msg := <-received:
+		if !strings.Contains(msg, "<14>1 ") {
+			t.Errorf("message = %q, want an RFC 5424 message", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for syslog message")
+	}
+}
@@ -0,0 +1,152 @@
+// tags.go: Per-record tags and their merge with the static tag set
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/agilira/iris"
+)
+
+// TagMergePolicy selects how a per-record tag is merged with a static tag
+// of the same key
+type TagMergePolicy int
+
+const (
+	// TagDynamicWins keeps the per-record value
+	TagDynamicWins TagMergePolicy = iota
+
+	// TagStaticWins keeps the Config.Tags (or UpdateTags) value
+	TagStaticWins
+
+	// TagKeepBoth sends both values as a multi-valued tag (key:v1,key:v2)
+	TagKeepBoth
+)
+
+// teamTag is the tag key Datadog uses for ownership
+const teamTag = "team"
+
+// applyDerivedTags merges the tags derived from the configuration into
+// Config.Tags: DD_TAGS (with InheritAgentEnv), ResourceAttributes,
+// HostnameTagPattern and Team, in that order. Explicit tags win over the
+// derived ones, except for Team.
+func applyDerivedTags(config *Config) error {
+	if config.InheritAgentEnv {
+		applyAgentEnv(config)
+	}
+	applyResourceAttributes(config)
+	if err := applyHostnameTags(config); err != nil {
+		return err
+	}
+	applyTeam(config)
+	return nil
+}
+
+// derivedTags returns the tags applyDerivedTags adds below Config.Tags:
+// DD_TAGS (with InheritAgentEnv), ResourceAttributes and
+// HostnameTagPattern. New computes them once so UpdateTags can merge them
+// without reading the environment again.
+func derivedTags(config Config) (map[string]string, error) {
+	config.Tags = nil
+	if config.InheritAgentEnv {
+		applyAgentEnv(&config)
+	}
+	applyResourceAttributes(&config)
+	if err := applyHostnameTags(&config); err != nil {
+		return nil, err
+	}
+	return config.Tags, nil
+}
+
+// applyTeam adds Config.Team to the tags and, with RequireTeamTag, warns
+// when no team is configured
+func applyTeam(config *Config) {
+	if config.Team != "" {
+		tags := make(map[string]string, len(config.Tags)+1)
+		for key, value := range config.Tags {
+			tags[key] = value
+		}
+		tags[teamTag] = config.Team
+		config.Tags = tags
+		return
+	}
+	if _, ok := config.Tags[teamTag]; !ok && config.RequireTeamTag && config.OnError != nil {
+		config.OnError(errors.New("no team tag configured: set Config.Team or a \"team\" tag"))
+	}
+}
+
+// resolveTags returns the ddtags of an entry: the static tag set, merged
+// with the record's Config.TagsField tags when present
+func (w *Writer) resolveTags(record *iris.Record) string {
+	set := w.currentTags()
+	if w.config.TagsField == "" {
+		return set.ddtags
+	}
+	value, ok := lookupString(record, w.config.TagsField)
+	if !ok {
+		return set.ddtags
+	}
+	dynamic := parseDDTags(value)
+	if len(dynamic) == 0 {
+		return set.ddtags
+	}
+	return mergeTags(set.tags, dynamic, w.config.TagMergePolicy)
+}
+
+// mergeTags renders static and dynamic tags as one ddtags string, sorted
+// by key, resolving keys present in both according to policy
+func mergeTags(static, dynamic map[string]string, policy TagMergePolicy) string {
+	merged := make(map[string]string, len(static)+len(dynamic))
+	for key, value := range static {
+		merged[key] = value
+	}
+	var second map[string]string // Dynamic values kept by TagKeepBoth
+	for key, value := range dynamic {
+		current, exists := merged[key]
+		switch {
+		case !exists:
+			merged[key] = value
+		case policy == TagStaticWins || current == value:
+		case policy == TagKeepBoth:
+			if second == nil {
+				second = make(map[string]string)
+			}
+			second[key] = value
+		default:
+			merged[key] = value
+		}
+	}
+	if second == nil {
+		return formatTags(merged)
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys)+len(second))
+	for _, key := range keys {
+		parts = append(parts, formatTag(key, merged[key]))
+		if value, ok := second[key]; ok {
+			parts = append(parts, formatTag(key, value))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatTag renders a single key:value tag, or the bare key when value is
+// empty
+func formatTag(key, value string) string {
+	if value == "" {
+		return key
+	}
+	return key + ":" + value
+}
@@ -0,0 +1,149 @@
+// tags_test.go: Per-record tag merging tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_TagMergePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy TagMergePolicy
+		want   string
+	}{
+		{"dynamic wins", TagDynamicWins, "env:prod,region:eu,team:payments"},
+		{"static wins", TagStaticWins, "env:prod,region:us,team:payments"},
+		{"keep both", TagKeepBoth, "env:prod,region:us,region:eu,team:payments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &Writer{config: Config{
+				Tags:           map[string]string{"env": "prod", "region": "us"},
+				TagsField:      "dd.tags",
+				TagMergePolicy: tt.policy,
+			}}
+			writer.tags.Store(&tagSet{tags: writer.config.Tags, ddtags: writer.buildTagsString()})
+
+			record := iris.NewRecord(iris.Info, "message")
+			record.AddField(iris.Str("dd.tags", "region:eu,team:payments,env:prod"))
+			if got := writer.buildLogEntry(record).Tags; got != tt.want {
+				t.Errorf("Tags = %q, want %q", got, tt.want)
+			}
+
+			if got := writer.buildLogEntry(iris.NewRecord(iris.Info, "static only")).Tags; got != "env:prod,region:us" {
+				t.Errorf("Tags without TagsField = %q, want the static tags", got)
+			}
+		})
+	}
+}
+
+func TestNew_Team(t *testing.T) {
+	writer, err := New(Config{
+		APIKey: "test-key",
+		Team:   "payments",
+		Tags:   map[string]string{"team": "other", "env": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if got := writer.currentTags().ddtags; got != "env:prod,team:payments" {
+		t.Errorf("ddtags = %q, want Team to win", got)
+	}
+	writer.UpdateTags(map[string]string{"env": "staging"})
+	if got := writer.currentTags().ddtags; got != "env:staging,team:payments" {
+		t.Errorf("ddtags after UpdateTags = %q, want the team tag kept", got)
+	}
+}
+
+func TestWriter_UpdateTagsKeepsDerivedTags(t *testing.T) {
+	t.Setenv("DD_TAGS", "owner:sre,region:us")
+	writer, err := New(Config{
+		APIKey:             "test-key",
+		InheritAgentEnv:    true,
+		ResourceAttributes: map[string]string{"k8s.cluster.name": "prod-eu"},
+		Hostname:           "web-eu-01",
+		HostnameTagPattern: `^(?P<role>[a-z]+)-(?P<zone>[a-z]+)-\d+$`,
+		Team:               "payments",
+		Tags:               map[string]string{"env": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	want := "env:prod,kube_cluster_name:prod-eu,owner:sre,region:us,role:web,team:payments,zone:eu"
+	if got := writer.currentTags().ddtags; got != want {
+		t.Errorf("ddtags = %q, want %q", got, want)
+	}
+	writer.UpdateTags(map[string]string{"env": "staging", "region": "eu"})
+	want = "env:staging,kube_cluster_name:prod-eu,owner:sre,region:eu,role:web,team:payments,zone:eu"
+	if got := writer.currentTags().ddtags; got != want {
+		t.Errorf("ddtags after UpdateTags = %q, want %q", got, want)
+	}
+}
+
+func TestNew_RequireTeamTag(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		config Config
+		warned bool
+	}{
+		{"missing", Config{RequireTeamTag: true}, true},
+		{"team field", Config{RequireTeamTag: true, Team: "core"}, false},
+		{"team tag", Config{RequireTeamTag: true, Tags: map[string]string{"team": "core"}}, false},
+		{"not required", Config{}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var warned bool
+			tt.config.APIKey = "test-key"
+			tt.config.OnError = func(error) { warned = true }
+			writer, err := New(tt.config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			_ = writer.Close()
+			if warned != tt.warned {
+				t.Errorf("warned = %v, want %v", warned, tt.warned)
+			}
+		})
+	}
+}
+
+func TestWriter_UpdateTagsWithoutSideEffects(t *testing.T) {
+	t.Setenv("DD_TAGS", "owner:sre")
+	var warnings int
+	writer, err := New(Config{
+		APIKey:          "test-key",
+		InheritAgentEnv: true,
+		RequireTeamTag:  true,
+		Tags:            map[string]string{"tier": "base"},
+		Profiles:        map[string]Profile{"prod": {Tags: map[string]string{"tier": "prod"}}},
+		ActiveProfile:   "prod",
+		OnError:         func(error) { warnings++ },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	if warnings != 1 {
+		t.Fatalf("warnings at New = %d, want 1 for the missing team", warnings)
+	}
+
+	t.Setenv("DD_TAGS", "owner:changed")
+	writer.UpdateTags(map[string]string{"tier": "custom", "env": "prod"})
+	if want, got := "env:prod,owner:sre,tier:prod", writer.currentTags().ddtags; got != want {
+		t.Errorf("ddtags after UpdateTags = %q, want %q", got, want)
+	}
+	if warnings != 1 {
+		t.Errorf("warnings after UpdateTags = %d, want no new RequireTeamTag warning", warnings)
+	}
+}
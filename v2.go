@@ -0,0 +1,157 @@
+// v2.go: Typed representation of the Datadog v2 logs intake format
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+)
+
+// V2LogEntry is a single item of the Datadog v2 logs intake payload
+// (POST /api/v2/logs). The schema fixes ddsource, ddtags, hostname,
+// message and service; everything else, including status and timestamp,
+// travels as an additional top-level attribute.
+type V2LogEntry struct {
+	DDSource string `json:"ddsource,omitempty"`
+	DDTags   string `json:"ddtags,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	Message  string `json:"message"`
+	Service  string `json:"service,omitempty"`
+
+	// Attributes holds additional top-level attributes
+	Attributes map[string]any `json:"-"`
+}
+
+// v2Reserved lists the keys defined by the v2 schema
+var v2Reserved = []string{"ddsource", "ddtags", "hostname", "message", "service"}
+
+// ToV2 converts entries to the v2 intake representation. Status,
+// timestamp, env, version and custom fields become attributes.
+func ToV2(entries []LogEntry) []V2LogEntry {
+	out := make([]V2LogEntry, len(entries))
+	for i, entry := range entries {
+		attributes := make(map[string]any, len(entry.Fields)+4)
+		for key, value := range entry.Fields {
+			attributes[key] = value
+		}
+		attributes["status"] = entry.Level
+		attributes["timestamp"] = entry.Timestamp
+		if entry.Env != "" {
+			attributes["env"] = entry.Env
+		}
+		if entry.Version != "" {
+			attributes["version"] = entry.Version
+		}
+
+		out[i] = V2LogEntry{
+			DDSource:   entry.Source,
+			DDTags:     entry.Tags,
+			Hostname:   entry.Hostname,
+			Message:    entry.Message,
+			Service:    entry.Service,
+			Attributes: attributes,
+		}
+	}
+	return out
+}
+
+// LogEntry converts a v2 item back to a LogEntry, lifting the status,
+// timestamp, env and version attributes into their dedicated fields.
+func (e V2LogEntry) LogEntry() LogEntry {
+	entry := LogEntry{
+		Message:  e.Message,
+		Service:  e.Service,
+		Source:   e.DDSource,
+		Tags:     e.DDTags,
+		Hostname: e.Hostname,
+		Fields:   make(map[string]any, len(e.Attributes)),
+	}
+
+	for key, value := range e.Attributes {
+		switch key {
+		case "status":
+			entry.Level, _ = value.(string)
+		case "timestamp":
+			entry.Timestamp = toInt64(value)
+		case "env":
+			entry.Env, _ = value.(string)
+		case "version":
+			entry.Version, _ = value.(string)
+		default:
+			entry.Fields[key] = value
+		}
+	}
+	return entry
+}
+
+// MarshalJSON flattens the attributes next to the schema fields. Schema
+// fields take precedence over attributes with the same key.
+func (e V2LogEntry) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(e.Attributes)+len(v2Reserved))
+	for key, value := range e.Attributes {
+		out[key] = value
+	}
+
+	type plain V2LogEntry
+	fixed, err := json.Marshal(plain(e))
+	if err != nil {
+		return nil, err
+	}
+	var reserved map[string]json.RawMessage
+	if err := json.Unmarshal(fixed, &reserved); err != nil {
+		return nil, err
+	}
+	for key, value := range reserved {
+		out[key] = value
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON splits a flat v2 item into schema fields and attributes.
+func (e *V2LogEntry) UnmarshalJSON(data []byte) error {
+	type plain V2LogEntry
+	var fixed plain
+	if err := json.Unmarshal(data, &fixed); err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, key := range v2Reserved {
+		delete(raw, key)
+	}
+
+	fixed.Attributes = make(map[string]any, len(raw))
+	for key, value := range raw {
+		var decoded any
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return err
+		}
+		fixed.Attributes[key] = decoded
+	}
+
+	*e = V2LogEntry(fixed)
+	return nil
+}
+
+// toInt64 converts a decoded JSON number to int64.
+func toInt64(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,75 @@
+// v2_test.go: Datadog v2 logs intake format tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestV2LogEntry_RoundTrip(t *testing.T) {
+	original := LogEntry{
+		Timestamp: 1757160000123,
+		Level:     "error",
+		Message:   "payment failed",
+		Service:   "billing",
+		Source:    "go",
+		Tags:      "env:production,team:core",
+		Hostname:  "web-01",
+		Env:       "production",
+		Version:   "1.2.3",
+		Fields:    map[string]any{"order_id": "A-1"},
+	}
+
+	payload, err := json.Marshal(ToV2([]LogEntry{original}))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var flat []map[string]any
+	if err := json.Unmarshal(payload, &flat); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	for _, key := range []string{"ddsource", "ddtags", "hostname", "message", "service", "status", "timestamp", "env", "version", "order_id"} {
+		if _, ok := flat[0][key]; !ok {
+			t.Errorf("Expected top-level key %q in %s", key, payload)
+		}
+	}
+
+	var decoded []V2LogEntry
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("Unmarshal into V2LogEntry failed: %v", err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(decoded))
+	}
+
+	if got := decoded[0].LogEntry(); !reflect.DeepEqual(got, original) {
+		t.Errorf("Round trip mismatch:\n got  %+v\n want %+v", got, original)
+	}
+}
+
+func TestV2LogEntry_SchemaFieldsWin(t *testing.T) {
+	entry := V2LogEntry{
+		Message:    "real message",
+		Attributes: map[string]any{"message": "shadowed"},
+	}
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var flat map[string]any
+	if err := json.Unmarshal(payload, &flat); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if flat["message"] != "real message" {
+		t.Errorf("message = %v, want schema field to win", flat["message"])
+	}
+}
@@ -0,0 +1,76 @@
+// validate.go: Strict validation of entries for development builds
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrInvalidEntry matches every ValidationError with errors.Is
+var ErrInvalidEntry = errors.New("invalid log entry")
+
+// ValidationError reports why Config.StrictMode rejected a record
+type ValidationError struct {
+	// Index is the position of the record in the write call; always 0
+	// for WriteRecord
+	Index int
+
+	// Field is the offending attribute, or "message"
+	Field string
+
+	// Reason describes the violation
+	Reason string
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid log entry %d: %s: %s", e.Index, e.Field, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidEntry) true
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidEntry
+}
+
+// validateEntry returns the first violation that lenient mode would have
+// fixed silently: a message it truncates (over both LargeEntryBytes and
+// MaxMessageBytes, see truncatesMessage) or needing sanitizing, a
+// value over MaxFieldValueBytes, or a value that cannot be encoded.
+// Fields are checked in key order so the reported violation is stable.
+func (w *Writer) validateEntry(entry LogEntry, index int) error {
+	invalid := func(field, format string, args ...any) error {
+		return &ValidationError{Index: index, Field: field, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if w.truncatesMessage(len(entry.Message)) {
+		return invalid("message", "%d bytes exceeds MaxMessageBytes %d", len(entry.Message), w.config.MaxMessageBytes)
+	}
+	if w.config.SanitizeMessages && sanitizeMessage(entry.Message, w.config.SanitizeMode) != entry.Message {
+		return invalid("message", "contains control characters")
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(entry.Fields)) {
+		value := entry.Fields[key]
+		if !encodable(value) {
+			return invalid(key, "unsupported type %T", value)
+		}
+		limit := w.config.MaxFieldValueBytes
+		if limit <= 0 {
+			continue
+		}
+		if s, ok := value.(string); ok && len(s) > limit {
+			return invalid(key, "%d bytes exceeds MaxFieldValueBytes %d", len(s), limit)
+		}
+		if _, encoded, ok := trimSlice(value, limit); ok {
+			return invalid(key, "%d encoded bytes exceeds MaxFieldValueBytes %d", encoded, limit)
+		}
+	}
+	return nil
+}
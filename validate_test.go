@@ -0,0 +1,112 @@
+// validate_test.go: Strict mode validation tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agilira/iris"
+)
+
+func TestWriter_StrictModeRejectsInvalidRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		msg    string
+		field  string
+		reason string
+	}{
+		{"oversized message", Config{LargeEntryBytes: 4, MaxMessageBytes: 8}, "far too long", "message", "exceeds MaxMessageBytes"},
+		{"control characters", Config{SanitizeMessages: true}, "line\x00break", "message", "control characters"},
+		{"oversized value", Config{MaxFieldValueBytes: 4, DefaultFields: map[string]any{"blob": "abcdefgh"}}, "ok", "blob", "exceeds MaxFieldValueBytes"},
+		{"unsupported type", Config{DefaultFields: map[string]any{"ch": make(chan int)}}, "ok", "ch", "unsupported type chan int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.CaptureMode = true
+			config.StrictMode = true
+			writer, err := New(config)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer func() { _ = writer.Close() }()
+
+			err = writer.WriteRecord(iris.NewRecord(iris.Info, tt.msg))
+			var validation *ValidationError
+			if !errors.As(err, &validation) || !errors.Is(err, ErrInvalidEntry) {
+				t.Fatalf("WriteRecord() error = %v, want *ValidationError", err)
+			}
+			if validation.Index != 0 || validation.Field != tt.field || !strings.Contains(validation.Reason, tt.reason) {
+				t.Errorf("ValidationError = %+v, want field %q, reason %q", validation, tt.field, tt.reason)
+			}
+			if stats := writer.Stats(); stats.EntriesInvalid != 1 || stats.EntriesDropped != 1 {
+				t.Errorf("Stats() = %+v, want 1 invalid, 1 dropped", stats)
+			}
+		})
+	}
+}
+
+func TestWriter_LenientModeStillSanitizes(t *testing.T) {
+	writer, err := New(Config{
+		CaptureMode:        true,
+		MaxFieldValueBytes: 4,
+		DefaultFields:      map[string]any{"blob": "abcdefgh"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := writer.WriteRecord(iris.NewRecord(iris.Info, "ok")); err != nil {
+		t.Errorf("WriteRecord() error = %v, want lenient truncation", err)
+	}
+	_ = writer.Close()
+	if got := writer.Stats().FieldsTruncated; got != 1 {
+		t.Errorf("FieldsTruncated = %d, want 1", got)
+	}
+}
+
+func TestWriter_StrictModeMatchesLenientTruncation(t *testing.T) {
+	// Lenient mode only truncates isolated large entries, so a message
+	// over MaxMessageBytes but not LargeEntryBytes is sent whole
+	for _, strict := range []bool{false, true} {
+		writer, err := New(Config{CaptureMode: true, StrictMode: strict, MaxMessageBytes: 8, LargeEntryBytes: 64})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if err := writer.WriteRecord(iris.NewRecord(iris.Info, "longer than eight")); err != nil {
+			t.Errorf("StrictMode %v: WriteRecord() error = %v, want the message accepted", strict, err)
+		}
+		_ = writer.Close()
+		batches := writer.CapturedBatches()
+		if len(batches) != 1 || batches[0].Entries[0].Message != "longer than eight" {
+			t.Errorf("StrictMode %v: batches = %+v, want the message sent whole", strict, batches)
+		}
+	}
+}
+
+func TestWriter_WriteRecordsReportsIndex(t *testing.T) {
+	writer, err := New(Config{CaptureMode: true, StrictMode: true, LargeEntryBytes: 8, MaxMessageBytes: 8})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	err = writer.WriteRecords([]*iris.Record{
+		iris.NewRecord(iris.Info, "ok"),
+		iris.NewRecord(iris.Info, "much too long"),
+		iris.NewRecord(iris.Info, "ok too"),
+	})
+	var validation *ValidationError
+	if !errors.As(err, &validation) || validation.Index != 1 {
+		t.Errorf("WriteRecords() error = %v, want ValidationError at index 1", err)
+	}
+	if got := len(writer.Snapshot()); got != 2 {
+		t.Errorf("buffered %d entries, want the 2 valid ones", got)
+	}
+}
@@ -0,0 +1,477 @@
+// wal.go: Write-ahead log for crash-consistent delivery
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultWALSegmentBytes is the size at which a WAL segment is rotated
+	defaultWALSegmentBytes = 16 << 20
+
+	// defaultWALMaxBytes bounds the total size of a WAL directory
+	defaultWALMaxBytes = 256 << 20
+
+	// walSuffix is the file extension of WAL segments
+	walSuffix = ".wal"
+
+	// walOriginKey tags a line that replaces an earlier line of the same
+	// entry, after a continuation join or coalescing changed it, with the
+	// position the entry was first logged at
+	walOriginKey = "dd.wal_origin"
+)
+
+// walRef is the position of a line in the WAL: its segment and line number
+type walRef struct {
+	segment int64
+	line    int
+}
+
+// String renders the reference as "segment:line"
+func (r walRef) String() string {
+	return strconv.FormatInt(r.segment, 10) + ":" + strconv.Itoa(r.line)
+}
+
+// parseWALRef parses a reference rendered by walRef.String
+func parseWALRef(text string) (walRef, bool) {
+	segment, line, ok := strings.Cut(text, ":")
+	if !ok {
+		return walRef{}, false
+	}
+	id, err1 := strconv.ParseInt(segment, 10, 64)
+	n, err2 := strconv.Atoi(line)
+	return walRef{segment: id, line: n}, err1 == nil && err2 == nil
+}
+
+// walSegment tracks one segment file and its unconfirmed entries
+type walSegment struct {
+	path     string
+	size     int64
+	lines    int // Lines written, numbering the next one
+	pending  int
+	sealed   bool // No longer written to
+	leftover bool // Left by a previous process; pending is set on replay
+}
+
+// wal appends buffered entries to segment files and deletes a segment
+// once every entry in it was confirmed by Datadog or dropped by policy.
+// Entries of failed batches stay on disk and are re-sent after a restart.
+type wal struct {
+	dir          string
+	segmentBytes int64
+	maxBytes     int64
+	sync         bool
+	onError      func(error)
+
+	mu       sync.Mutex
+	segments map[int64]*walSegment
+	order    []int64 // Segment IDs, oldest first
+	current  *os.File
+	id       int64 // ID of the current segment
+	total    int64 // Bytes across all live segments
+	lost     uint64
+}
+
+// openWAL prepares dir and returns the WAL plus the paths of segments left
+// by a previous process, oldest first, for replay. Those segments count
+// towards Config.WALMaxBytes until their entries are confirmed.
+func openWAL(config Config) (*wal, []string, error) {
+	if err := os.MkdirAll(config.WALDir, 0o750); err != nil {
+		return nil, nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+	names, err := filepath.Glob(filepath.Join(config.WALDir, "*"+walSuffix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list WAL segments: %w", err)
+	}
+	sort.Strings(names)
+
+	l := &wal{
+		dir:          config.WALDir,
+		segmentBytes: int64(config.WALSegmentBytes),
+		maxBytes:     int64(config.WALMaxBytes),
+		sync:         config.WALSync,
+		onError:      config.OnError,
+		segments:     make(map[int64]*walSegment),
+	}
+	for _, name := range names {
+		id, ok := walSegmentID(name)
+		if !ok {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		l.segments[id] = &walSegment{path: name, size: info.Size(), sealed: true, leftover: true}
+		l.order = append(l.order, id)
+		l.total += info.Size()
+		l.id = max(l.id, id)
+	}
+	l.enforceMax()
+
+	replay := make([]string, 0, len(l.order))
+	for _, id := range l.order {
+		replay = append(replay, l.segments[id].path)
+	}
+	return l, replay, nil
+}
+
+// walSegmentID parses the ID from a segment file name
+func walSegmentID(path string) (int64, bool) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(path), walSuffix), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// append writes entry to the current segment and stores its position in
+// the entry. Errors are reported and leave the entry as it was, unlogged
+// rather than blocking delivery, and append reports false.
+func (l *wal) append(entry *LogEntry) bool {
+	logged := *entry
+	if entry.walOrigin != (walRef{}) {
+		logged.Fields = maps.Clone(entry.Fields)
+		if logged.Fields == nil {
+			logged.Fields = make(map[string]any, 1)
+		}
+		logged.Fields[walOriginKey] = entry.walOrigin.String()
+	}
+	line, err := json.Marshal(logged)
+	if err != nil {
+		l.report(fmt.Errorf("WAL: failed to encode entry: %w", err))
+		return false
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil || l.segments[l.id].size >= l.segmentBytes {
+		if err := l.rotate(); err != nil {
+			l.report(err)
+			return false
+		}
+	}
+	if _, err := l.current.Write(line); err != nil {
+		l.report(fmt.Errorf("WAL: failed to write entry: %w", err))
+		return false
+	}
+	if l.sync {
+		if err := l.current.Sync(); err != nil {
+			l.report(fmt.Errorf("WAL: failed to sync segment: %w", err))
+		}
+	}
+
+	segment := l.segments[l.id]
+	if entry.walOrigin == (walRef{}) {
+		entry.walOrigin = walRef{segment: l.id, line: segment.lines}
+	}
+	entry.walSegment = l.id
+	segment.lines++
+	segment.size += int64(len(line))
+	segment.pending++
+	l.total += int64(len(line))
+	l.enforceMax()
+	return true
+}
+
+// rewrite logs the current form of a logged entry after a continuation
+// join or coalescing changed it, and releases the line it replaces.
+// Replay keeps only the newest line of each entry.
+func (l *wal) rewrite(entry *LogEntry) {
+	if l == nil || entry.walSegment == 0 {
+		return
+	}
+	previous := *entry
+	if l.append(entry) {
+		l.release([]LogEntry{previous})
+	}
+}
+
+// adopt sets the pending count of a leftover segment once replay knows
+// how many of its entries it re-sends, removing it when there are none.
+// It reports false when the segment was discarded by Config.WALMaxBytes.
+func (l *wal) adopt(id int64, pending int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	segment, ok := l.segments[id]
+	if !ok {
+		return false
+	}
+	segment.leftover = false
+	segment.pending = pending
+	if pending <= 0 {
+		l.remove(id)
+	}
+	return true
+}
+
+// rotate seals the current segment and opens the next one. Must be called
+// with mu held.
+func (l *wal) rotate() error {
+	if l.current != nil {
+		_ = l.current.Close()
+		l.current = nil
+		l.seal(l.id)
+	}
+
+	id := l.id + 1
+	path := filepath.Join(l.dir, fmt.Sprintf("%020d%s", id, walSuffix))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) // #nosec G304 -- path is built from Config.WALDir
+	if err != nil {
+		return fmt.Errorf("WAL: failed to open segment: %w", err)
+	}
+	l.current = file
+	l.id = id
+	l.segments[id] = &walSegment{path: path}
+	l.order = append(l.order, id)
+	return nil
+}
+
+// seal marks a segment complete, removing it if nothing is pending. Must
+// be called with mu held.
+func (l *wal) seal(id int64) {
+	if segment, ok := l.segments[id]; ok {
+		segment.sealed = true
+		if segment.pending <= 0 {
+			l.remove(id)
+		}
+	}
+}
+
+// remove deletes a segment file. Must be called with mu held.
+func (l *wal) remove(id int64) {
+	segment, ok := l.segments[id]
+	if !ok {
+		return
+	}
+	if err := os.Remove(segment.path); err != nil && !os.IsNotExist(err) {
+		l.report(fmt.Errorf("WAL: failed to remove segment: %w", err))
+	}
+	l.total -= segment.size
+	delete(l.segments, id)
+	for i, current := range l.order {
+		if current == id {
+			l.order = append(l.order[:i], l.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// enforceMax drops the oldest sealed segments while the WAL is over
+// Config.WALMaxBytes. Must be called with mu held.
+func (l *wal) enforceMax() {
+	for l.maxBytes > 0 && l.total > l.maxBytes && len(l.order) > 1 {
+		oldest := l.segments[l.order[0]]
+		if oldest.leftover {
+			l.lost += uint64(countLines(oldest.path))
+		} else {
+			l.lost += uint64(oldest.pending)
+		}
+		l.remove(l.order[0])
+	}
+}
+
+// countLines returns the number of entries in a segment file, 0 if it
+// cannot be read
+func countLines(path string) int {
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from Config.WALDir
+	if err != nil {
+		return 0
+	}
+	return bytes.Count(data, []byte{'\n'})
+}
+
+// release marks entries as done, confirmed or deliberately dropped, and
+// deletes sealed segments with nothing left pending
+func (l *wal) release(entries []LogEntry) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range entries {
+		id := entries[i].walSegment
+		segment, ok := l.segments[id]
+		if id == 0 || !ok {
+			continue
+		}
+		segment.pending--
+		if segment.sealed && segment.pending <= 0 {
+			l.remove(id)
+		}
+	}
+}
+
+// close seals the current segment
+func (l *wal) close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current != nil {
+		_ = l.current.Close()
+		l.current = nil
+		l.seal(l.id)
+	}
+}
+
+// lostEntries returns the number of unconfirmed entries discarded by
+// Config.WALMaxBytes
+func (l *wal) lostEntries() uint64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lost
+}
+
+func (l *wal) report(err error) {
+	if l.onError != nil {
+		l.onError(err)
+	}
+}
+
+// replayWAL re-sends the segments left by a previous process through the
+// normal delivery path. Only the newest line of each entry is sent. The
+// entries stay tracked in their segment, which is deleted once all of
+// them were confirmed or dropped by policy, so entries of failed batches,
+// or requeued during a cooldown, are still on disk after another crash.
+func (w *Writer) replayWAL(paths []string) {
+	defer w.replaying.Done()
+
+	// A first pass finds the newest line of each entry across segments
+	latest := make(map[walRef]walRef)
+	for _, path := range paths {
+		lines, err := readWALSegment(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range lines {
+			latest[line.entry.walOrigin] = line.at
+		}
+	}
+
+	for _, path := range paths {
+		lines, err := readWALSegment(path)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				w.handleError(err)
+			}
+			continue
+		}
+		entries := make([]LogEntry, 0, len(lines))
+		for _, line := range lines {
+			if latest[line.entry.walOrigin] == line.at {
+				entries = append(entries, line.entry)
+			}
+		}
+		id, _ := walSegmentID(path)
+		if !w.wal.adopt(id, len(entries)) || len(entries) == 0 {
+			continue
+		}
+		if err := w.deliver(entries); err == nil {
+			w.stats.walReplayed.Add(uint64(len(entries)))
+		}
+	}
+}
+
+// walLine is a decoded WAL line and its position
+type walLine struct {
+	entry LogEntry
+	at    walRef
+}
+
+// readWALSegment decodes the entries of a segment file, tracked in that
+// segment and carrying the position they were first logged at. A torn
+// last line from a crash mid-write is ignored.
+func readWALSegment(path string) ([]walLine, error) {
+	id, ok := walSegmentID(path)
+	if !ok {
+		return nil, fmt.Errorf("WAL: unexpected segment name %q", filepath.Base(path))
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path comes from Config.WALDir
+	if err != nil {
+		return nil, fmt.Errorf("WAL: failed to read segment: %w", err)
+	}
+
+	var lines []walLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for n := 0; scanner.Scan(); n++ {
+		entry, err := decodeWALEntry(scanner.Bytes())
+		if err != nil {
+			continue
+		}
+		at := walRef{segment: id, line: n}
+		entry.walSegment = id
+		entry.walOrigin = at
+		if tag, ok := entry.Fields[walOriginKey].(string); ok {
+			if origin, ok := parseWALRef(tag); ok {
+				entry.walOrigin = origin
+			}
+			delete(entry.Fields, walOriginKey)
+			if len(entry.Fields) == 0 {
+				entry.Fields = nil
+			}
+		}
+		lines = append(lines, walLine{entry: entry, at: at})
+	}
+	return lines, nil
+}
+
+// decodeWALEntry rebuilds an entry from its MarshalJSON form, returning
+// attributes to Fields. Numbers are kept as json.Number so they encode
+// back unchanged.
+func decodeWALEntry(line []byte) (LogEntry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return LogEntry{}, err
+	}
+
+	text := func(key string) string {
+		value, _ := raw[key].(string)
+		delete(raw, key)
+		return value
+	}
+	entry := LogEntry{
+		Level:    text("status"),
+		Message:  text("message"),
+		Service:  text("service"),
+		Source:   text("ddsource"),
+		Tags:     text("ddtags"),
+		Hostname: text("hostname"),
+		Env:      text("env"),
+		Version:  text("version"),
+	}
+	if ts, ok := raw["timestamp"].(json.Number); ok {
+		entry.Timestamp, _ = ts.Int64()
+	}
+	delete(raw, "timestamp")
+	if len(raw) > 0 {
+		entry.Fields = raw
+	}
+	return entry, nil
+}
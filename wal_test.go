@@ -0,0 +1,302 @@
+// wal_test.go: Write-ahead log tests
+//
+// Copyright (c) 2025 AGILira
+// Series: an AGILira library
+// SPDX-License-Identifier: MPL-2.0
+
+package datadogwriter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agilira/iris"
+)
+
+func walSegments(t *testing.T, dir string) []string {
+	t.Helper()
+	names, err := filepath.Glob(filepath.Join(dir, "*"+walSuffix))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	return names
+}
+
+func TestWriter_WALRemovesConfirmedEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writer, err := New(Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval: time.Hour,
+		WALDir:        dir,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = writer.WriteRecord(iris.NewRecord(iris.Info, "durable"))
+	if got := walSegments(t, dir); len(got) != 1 {
+		t.Fatalf("segments before flush = %v, want 1", got)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := walSegments(t, dir); len(got) != 0 {
+		t.Errorf("segments after delivery = %v, want none", got)
+	}
+}
+
+func TestWriter_WALReplaysAfterRestart(t *testing.T) {
+	var healthy atomic.Bool
+	var delivered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var entries []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		delivered.Add(int32(len(entries)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval: time.Hour,
+		MaxRetries:    1,
+		RetryDelay:    time.Millisecond,
+		WALDir:        dir,
+	}
+
+	first, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = first.WriteRecord(iris.NewRecord(iris.Error, "one"))
+	_ = first.WriteRecord(iris.NewRecord(iris.Error, "two"))
+	_ = first.Close()
+	if got := walSegments(t, dir); len(got) != 1 {
+		t.Fatalf("segments after failed delivery = %v, want 1", got)
+	}
+
+	healthy.Store(true)
+	second, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := second.Stats().WALReplayed; got != 2 {
+		t.Errorf("WALReplayed = %d, want 2", got)
+	}
+	if got := delivered.Load(); got != 2 {
+		t.Errorf("delivered = %d, want 2", got)
+	}
+	if got := walSegments(t, dir); len(got) != 0 {
+		t.Errorf("segments after replay = %v, want none", got)
+	}
+}
+
+func TestWriter_WALKeepsMergedEntries(t *testing.T) {
+	var healthy atomic.Bool
+	var received []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var entries []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		received = append(received, entries...)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := Config{
+		APIKey:             "test-key",
+		Site:               strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval:      time.Hour,
+		MaxRetries:         1,
+		RetryDelay:         time.Millisecond,
+		WALDir:             dir,
+		WALSegmentBytes:    200, // Merges land in later segments
+		CoalesceDuplicates: true,
+		JoinContinuations:  true,
+		OnError:            func(error) {},
+	}
+
+	first, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_ = first.WriteRecord(iris.NewRecord(iris.Warn, "disk almost full"))
+	}
+	_ = first.WriteRecord(iris.NewRecord(iris.Error, "panic: boom"))
+	continuation := iris.NewRecord(iris.Error, "goroutine 1 [running]")
+	continuation.AddField(iris.Bool(continuationKey, true))
+	_ = first.WriteRecord(continuation)
+	_ = first.Close()
+
+	healthy.Store(true)
+	second, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("replayed %d entries, want the 2 merged entries: %v", len(received), received)
+	}
+	if received[0]["message"] != "disk almost full" || received[0][repeatCountKey] != float64(3) {
+		t.Errorf("coalesced entry = %v, want dd.repeat_count 3", received[0])
+	}
+	if received[1]["message"] != "panic: boom\ngoroutine 1 [running]" {
+		t.Errorf("joined entry = %v, want the continuation appended", received[1])
+	}
+	if _, ok := received[0][walOriginKey]; ok {
+		t.Errorf("replayed entry leaks %s: %v", walOriginKey, received[0])
+	}
+	if got := walSegments(t, dir); len(got) != 0 {
+		t.Errorf("segments after replay = %v, want none", got)
+	}
+}
+
+func TestWriter_WALKeepsRequeuedReplay(t *testing.T) {
+	var rateLimited atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rateLimited.Load() {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := Config{
+		APIKey:        "test-key",
+		Site:          strings.TrimPrefix(server.URL, "http://"),
+		FlushInterval: time.Hour,
+		MaxRetries:    1,
+		RetryDelay:    time.Millisecond,
+		WALDir:        dir,
+		DrainTimeout:  100 * time.Millisecond, // Close gives up on the 30s cooldown
+		OnError:       func(error) {},
+	}
+
+	first, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_ = first.WriteRecord(iris.NewRecord(iris.Error, "one"))
+	_ = first.Close()
+
+	// The replay is requeued by a cooldown, then the process dies
+	rateLimited.Store(true)
+	second, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	second.replaying.Wait()
+	if got := len(second.Snapshot()); got != 1 {
+		t.Fatalf("buffered after replay = %d, want the requeued entry", got)
+	}
+	if got := walSegments(t, dir); len(got) != 1 {
+		t.Errorf("segments after requeued replay = %v, want the segment kept", got)
+	}
+	_ = second.Close()
+	if got := walSegments(t, dir); len(got) != 1 {
+		t.Errorf("segments after Close = %v, want the undelivered segment kept", got)
+	}
+}
+
+func TestWAL_LeftoverSegmentsCountTowardsMax(t *testing.T) {
+	dir := t.TempDir()
+	l, _, err := openWAL(Config{WALDir: dir, WALSegmentBytes: 200, WALMaxBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("openWAL() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		l.append(&LogEntry{Timestamp: int64(i), Level: "info", Message: strings.Repeat("x", 80)})
+	}
+	l.close()
+	if got := len(walSegments(t, dir)); got < 5 {
+		t.Fatalf("segments = %d, want several", got)
+	}
+
+	restarted, replay, err := openWAL(Config{WALDir: dir, WALSegmentBytes: 200, WALMaxBytes: 600})
+	if err != nil {
+		t.Fatalf("openWAL() error = %v", err)
+	}
+	defer restarted.close()
+	if restarted.total > 600+200 {
+		t.Errorf("total = %d, want leftover segments bounded by WALMaxBytes", restarted.total)
+	}
+	if got := len(walSegments(t, dir)); got != len(replay) {
+		t.Errorf("segments on disk = %d, replay = %d, want only the kept ones replayed", got, len(replay))
+	}
+	if restarted.lostEntries() == 0 {
+		t.Error("lostEntries = 0, want discarded leftover entries counted")
+	}
+}
+
+func TestWAL_RotatesAndBoundsSize(t *testing.T) {
+	dir := t.TempDir()
+	l, _, err := openWAL(Config{WALDir: dir, WALSegmentBytes: 200, WALMaxBytes: 600})
+	if err != nil {
+		t.Fatalf("openWAL() error = %v", err)
+	}
+	defer l.close()
+
+	for i := 0; i < 20; i++ {
+		l.append(&LogEntry{Timestamp: int64(i), Level: "info", Message: strings.Repeat("x", 80)})
+	}
+	if got := len(walSegments(t, dir)); got < 2 || got > 4 {
+		t.Errorf("segments = %d, want rotation bounded by WALMaxBytes", got)
+	}
+	if l.lostEntries() == 0 {
+		t.Error("lostEntries = 0, want oldest segments discarded")
+	}
+}
+
+func TestDecodeWALEntry(t *testing.T) {
+	original := LogEntry{
+		Timestamp: 1700000000123,
+		Level:     "error",
+		Message:   "failed",
+		Service:   "api",
+		Tags:      "env:prod",
+		Fields:    map[string]any{"user_id": 12345, "path": "/v1"},
+	}
+	line, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	decoded, err := decodeWALEntry(line)
+	if err != nil {
+		t.Fatalf("decodeWALEntry() error = %v", err)
+	}
+	again, _ := json.Marshal(decoded)
+	if string(again) != string(line) {
+		t.Errorf("round trip = %s, want %s", again, line)
+	}
+}